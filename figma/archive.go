@@ -0,0 +1,79 @@
+package figma
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"figma-mcp-server/types"
+)
+
+// ArchiveManifestName zip包中清单文件的名称
+const ArchiveManifestName = "manifest.json"
+
+// ArchiveManifest zip包中的清单，记录来源文件和每个输出文件的结果
+type ArchiveManifest struct {
+	FileKey     string                 `json:"fileKey"`
+	GeneratedAt string                 `json:"generatedAt"`
+	Files       []types.DownloadResult `json:"files"`
+	Generated   []types.GeneratedFile  `json:"generated,omitempty"`
+}
+
+// BuildArchive 将已下载的文件和清单打包为zip，文件按fileName放置
+func BuildArchive(fileKey string, report *types.DownloadReport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	modified := time.Now()
+
+	for _, result := range report.Results {
+		if result.Data == nil {
+			continue
+		}
+		if err := writeArchiveFile(zw, result.FileName, result.Data, modified); err != nil {
+			return nil, err
+		}
+	}
+
+	// 雪碧图、组件和索引文件
+	for _, file := range report.Generated {
+		if err := writeArchiveFile(zw, file.FileName, file.Data, modified); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(ArchiveManifest{
+		FileKey:     fileKey,
+		GeneratedAt: modified.UTC().Format(time.RFC3339),
+		Files:       report.Results,
+		Generated:   report.Generated,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeArchiveFile(zw, ArchiveManifestName, manifest, modified); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeArchiveFile(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	return nil
+}
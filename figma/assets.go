@@ -0,0 +1,255 @@
+package figma
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"figma-mcp-server/types"
+)
+
+// ListFigmaAssets 遍历文件或节点，列出图片填充、矢量图标和配置了导出设置的节点
+// 返回的JSON中每一项都带有建议的文件名和格式，可以直接传给download_figma_images
+func ListFigmaAssets(figmaApiKey, fileKey, nodeId string, depth int) (string, error) {
+	var nodeIds []string
+	if nodeId != "" {
+		nodeIds = []string{nodeId}
+	}
+
+	resp, err := figmaGet(figmaApiKey, figmaDataURL(fileKey, nodeIds, depth))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var roots []types.FigmaNode
+	if nodeId != "" {
+		var apiResponse types.FigmaAPINodeResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+			return "", err
+		}
+		for _, nodeWrapper := range apiResponse.Nodes {
+			if nodeWrapper != nil {
+				roots = append(roots, nodeWrapper.Document)
+			}
+		}
+	} else {
+		var apiResponse types.FigmaAPIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+			return "", err
+		}
+		roots = apiResponse.Document.Children
+	}
+
+	assets := collectAssets(roots)
+
+	result := map[string]interface{}{
+		"fileKey": fileKey,
+		"assets":  assets,
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// collectAssets 遍历原始节点树收集可下载的资源
+// 同一个imageRef只列出一次，矢量图标的子树不再继续遍历
+func collectAssets(roots []types.FigmaNode) []types.DownloadCandidate {
+	assets := []types.DownloadCandidate{}
+	usedNames := make(map[string]int)
+	seenImageRefs := make(map[string]bool)
+
+	var walk func(node types.FigmaNode)
+	walk = func(node types.FigmaNode) {
+		if !isVisible(node) {
+			return
+		}
+
+		for _, f := range node.Fills {
+			fill, ok := f.(map[string]interface{})
+			if !ok || fill["type"] != "IMAGE" {
+				continue
+			}
+			imageRef, _ := fill["imageRef"].(string)
+			if imageRef == "" || seenImageRefs[imageRef] {
+				continue
+			}
+			seenImageRefs[imageRef] = true
+
+			// 图片填充按原始字节下载，格式在下载时识别，文件名不带扩展名
+			scaleMode, _ := fill["scaleMode"].(string)
+			assets = append(assets, types.DownloadCandidate{
+				Kind:      "imageFill",
+				NodeId:    node.ID,
+				Name:      node.Name,
+				ImageRef:  imageRef,
+				ScaleMode: scaleMode,
+				FileName:  suggestFileName(node.Name, "", usedNames),
+			})
+		}
+
+		for _, setting := range node.ExportSettings {
+			format := strings.ToLower(setting.Format)
+			asset := types.DownloadCandidate{
+				Kind:     "export",
+				NodeId:   node.ID,
+				Name:     node.Name,
+				FileName: uniqueFileName(slugify(node.Name)+setting.Suffix, format, usedNames),
+				Format:   format,
+			}
+			if format == "png" || format == "jpg" {
+				asset.Scale = exportScale(setting, node)
+			}
+			assets = append(assets, asset)
+		}
+
+		if isVectorOnly(node) {
+			if len(node.ExportSettings) == 0 {
+				assets = append(assets, types.DownloadCandidate{
+					Kind:     "vector",
+					NodeId:   node.ID,
+					Name:     node.Name,
+					FileName: suggestFileName(node.Name, "svg", usedNames),
+					Format:   "svg",
+				})
+			}
+			return
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	for _, root := range roots {
+		walk(root)
+	}
+
+	return assets
+}
+
+// isVectorOnly 判断节点是否可以作为一个整体导出为SVG：
+// 矢量节点本身，或者子树中只有矢量图形的框架/分组
+func isVectorOnly(node types.FigmaNode) bool {
+	switch node.Type {
+	case "VECTOR", "BOOLEAN_OPERATION", "STAR", "REGULAR_POLYGON":
+		return true
+	case "FRAME", "GROUP", "INSTANCE", "COMPONENT":
+		hasPath := false
+		return containsOnlyVectors(node, &hasPath) && hasPath
+	}
+	return false
+}
+
+// containsOnlyVectors 递归检查可见子节点是否全部为矢量图形且没有图片填充
+// 至少包含一个路径类节点，避免把只有矩形的背景框折叠掉
+func containsOnlyVectors(node types.FigmaNode, hasPath *bool) bool {
+	if hasImageFill(node) {
+		return false
+	}
+
+	visibleChildren := 0
+	for _, child := range node.Children {
+		if !isVisible(child) {
+			continue
+		}
+		visibleChildren++
+
+		switch child.Type {
+		case "VECTOR", "BOOLEAN_OPERATION", "STAR", "REGULAR_POLYGON", "LINE":
+			*hasPath = true
+			if hasImageFill(child) {
+				return false
+			}
+		case "RECTANGLE", "ELLIPSE":
+			if hasImageFill(child) {
+				return false
+			}
+		case "FRAME", "GROUP", "INSTANCE", "COMPONENT":
+			if !containsOnlyVectors(child, hasPath) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return visibleChildren > 0
+}
+
+// hasImageFill 检查节点是否使用了图片填充
+func hasImageFill(node types.FigmaNode) bool {
+	for _, f := range node.Fills {
+		if fill, ok := f.(map[string]interface{}); ok && fill["type"] == "IMAGE" {
+			return true
+		}
+	}
+	return false
+}
+
+// collectSVGCandidates 收集简化树中所有IMAGE-SVG节点作为下载候选
+func collectSVGCandidates(nodes []types.SimplifiedNode) []types.DownloadCandidate {
+	var candidates []types.DownloadCandidate
+	usedNames := make(map[string]int)
+
+	var walk func(nodes []types.SimplifiedNode)
+	walk = func(nodes []types.SimplifiedNode) {
+		for _, node := range nodes {
+			if node.Type == "IMAGE-SVG" {
+				candidates = append(candidates, types.DownloadCandidate{
+					NodeId:   node.ID,
+					Name:     node.Name,
+					FileName: suggestFileName(node.Name, "svg", usedNames),
+					Format:   "svg",
+				})
+				continue
+			}
+			walk(node.Children)
+		}
+	}
+	walk(nodes)
+
+	return candidates
+}
+
+// suggestFileName 根据节点名生成文件名，重名时追加序号
+func suggestFileName(name, ext string, usedNames map[string]int) string {
+	return uniqueFileName(slugify(name), ext, usedNames)
+}
+
+// uniqueFileName 在已使用的文件名中找到不冲突的名字，ext为空时不带扩展名
+func uniqueFileName(base, ext string, usedNames map[string]int) string {
+	if ext != "" {
+		ext = "." + ext
+	}
+	fileName := base + ext
+	for i := 2; usedNames[fileName] > 0; i++ {
+		fileName = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	usedNames[fileName]++
+	return fileName
+}
+
+// slugify 将节点名转换为小写、以-连接的文件名
+func slugify(name string) string {
+	var sb strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			sb.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if slug == "" {
+		return "asset"
+	}
+	return slug
+}
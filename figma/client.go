@@ -7,8 +7,6 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -27,43 +25,55 @@ var (
 
 // GetFigmaData 获取Figma文件数据，简化版本
 func GetFigmaData(figmaApiKey, fileKey, nodeId string, depth int) (string, error) {
-	url := fmt.Sprintf("https://api.figma.com/v1/files/%s", fileKey)
+	var nodeIds []string
 	if nodeId != "" {
-		url += fmt.Sprintf("/nodes?ids=%s", nodeId)
-		if depth > 0 {
-			url += fmt.Sprintf("&depth=%d", depth)
-		}
+		nodeIds = []string{nodeId}
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	resp, err := figmaGet(figmaApiKey, figmaDataURL(fileKey, nodeIds, depth))
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 
-	req.Header.Add("X-FIGMA-TOKEN", figmaApiKey)
+	var simplifiedDesign *types.SimplifiedDesign
+	if nodeId != "" {
+		simplifiedDesign, _, err = parseFigmaNodeResponse(resp.Body, nodeIds)
+	} else {
+		simplifiedDesign, err = parseFigmaFileResponse(resp.Body)
+	}
 
-	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Figma API错误: %d", resp.StatusCode)
-	}
+	return marshalDesign(simplifiedDesign, simplifiedDesign.Nodes)
+}
 
-	var simplifiedDesign *types.SimplifiedDesign
-	if nodeId != "" {
-		simplifiedDesign, err = parseFigmaNodeResponse(resp.Body)
-	} else {
-		simplifiedDesign, err = parseFigmaFileResponse(resp.Body)
+// GetFigmaNodes 用一次请求获取多个节点，nodes按请求的节点ID分组
+// 文件中不存在或已隐藏的节点记为null，而不是直接省略
+func GetFigmaNodes(figmaApiKey, fileKey string, nodeIds []string, depth int) (string, error) {
+	resp, err := figmaGet(figmaApiKey, figmaDataURL(fileKey, nodeIds, depth))
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
+	simplifiedDesign, requested, err := parseFigmaNodeResponse(resp.Body, nodeIds)
 	if err != nil {
 		return "", err
 	}
 
-	// 构建结果结构
+	grouped := make(yaml.MapSlice, 0, len(nodeIds))
+	for _, id := range nodeIds {
+		grouped = append(grouped, yaml.MapItem{Key: id, Value: requested[id]})
+	}
+
+	return marshalDesign(simplifiedDesign, grouped)
+}
+
+// marshalDesign 构建结果结构并输出为YAML
+func marshalDesign(simplifiedDesign *types.SimplifiedDesign, nodes interface{}) (string, error) {
 	result := map[string]interface{}{
 		"metadata": map[string]interface{}{
 			"name":         simplifiedDesign.Name,
@@ -72,9 +82,12 @@ func GetFigmaData(figmaApiKey, fileKey, nodeId string, depth int) (string, error
 		},
 		"components":    simplifiedDesign.Components,
 		"componentSets": simplifiedDesign.ComponentSets,
-		"nodes":         simplifiedDesign.Nodes,
+		"nodes":         nodes,
 		"globalVars":    simplifiedDesign.GlobalVars,
 	}
+	if len(simplifiedDesign.DownloadCandidates) > 0 {
+		result["downloadCandidates"] = simplifiedDesign.DownloadCandidates
+	}
 
 	yamlData, err := yaml.Marshal(result)
 	if err != nil {
@@ -84,6 +97,80 @@ func GetFigmaData(figmaApiKey, fileKey, nodeId string, depth int) (string, error
 	return string(yamlData), nil
 }
 
+// figmaDataURL 构建文件或节点数据的请求地址，多个节点ID以逗号分隔并经过转义
+// depth对整个文件同样生效：1只返回页面，2返回页面及其顶层节点
+func figmaDataURL(fileKey string, nodeIds []string, depth int) string {
+	apiURL := fmt.Sprintf("https://api.figma.com/v1/files/%s", fileKey)
+	params := url.Values{}
+	if len(nodeIds) > 0 {
+		apiURL += "/nodes"
+		params.Add("ids", strings.Join(nodeIds, ","))
+	}
+	if depth > 0 {
+		params.Add("depth", strconv.Itoa(depth))
+	}
+	if len(params) > 0 {
+		apiURL += "?" + params.Encode()
+	}
+	return apiURL
+}
+
+// figmaGet 发送带认证的GET请求，非200状态码视为错误
+func figmaGet(figmaApiKey, apiURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("X-FIGMA-TOKEN", figmaApiKey)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &apiError{statusCode: resp.StatusCode, retryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	return resp, nil
+}
+
+// apiError Figma API返回的非200状态码
+type apiError struct {
+	statusCode int
+	// retryAfter 429响应中Retry-After要求的等待时间，没有时为0
+	retryAfter time.Duration
+}
+
+func (e *apiError) Error() string {
+	if e.statusCode == http.StatusTooManyRequests && e.retryAfter > 0 {
+		return fmt.Sprintf("Figma API错误: %d (请求过于频繁，需要等待 %s)", e.statusCode, e.retryAfter)
+	}
+	return fmt.Sprintf("Figma API错误: %d", e.statusCode)
+}
+
+// retryable 400和5xx可以缩小批次后重试，认证失败、文件不存在等其他4xx重试也不会成功
+// 429需要按Retry-After等待，单独处理
+func (e *apiError) retryable() bool {
+	return e.statusCode == http.StatusBadRequest || e.statusCode >= 500
+}
+
+// retryAfter 解析Retry-After头，支持秒数和HTTP日期
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at))
+	}
+	return 0
+}
+
 // 简化的文件响应解析
 func parseFigmaFileResponse(body io.Reader) (*types.SimplifiedDesign, error) {
 	var apiResponse types.FigmaAPIResponse
@@ -99,24 +186,28 @@ func parseFigmaFileResponse(body io.Reader) (*types.SimplifiedDesign, error) {
 		ComponentSets: apiResponse.ComponentSets,
 		GlobalVars:    types.GlobalVars{Styles: make(map[string]interface{})},
 	}
+	vars := newGlobalVars(simplifiedDesign.GlobalVars.Styles)
 
 	// 简单处理子节点
 	for _, child := range apiResponse.Document.Children {
 		if isVisible(child) {
-			if node := parseNode(child, simplifiedDesign.GlobalVars.Styles, nil); node != nil {
+			if node := parseNode(child, vars, nil); node != nil {
 				simplifiedDesign.Nodes = append(simplifiedDesign.Nodes, *node)
 			}
 		}
 	}
 
+	simplifiedDesign.DownloadCandidates = collectSVGCandidates(simplifiedDesign.Nodes)
+
 	return simplifiedDesign, nil
 }
 
-// 简化的节点响应解析
-func parseFigmaNodeResponse(body io.Reader) (*types.SimplifiedDesign, error) {
+// 简化的节点响应解析，按请求的节点ID顺序解析
+// 返回的map以请求的节点ID为键，不存在或已隐藏的节点值为nil
+func parseFigmaNodeResponse(body io.Reader, nodeIds []string) (*types.SimplifiedDesign, map[string]*types.SimplifiedNode, error) {
 	var apiResponse types.FigmaAPINodeResponse
 	if err := json.NewDecoder(body).Decode(&apiResponse); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	simplifiedDesign := &types.SimplifiedDesign{
@@ -127,9 +218,13 @@ func parseFigmaNodeResponse(body io.Reader) (*types.SimplifiedDesign, error) {
 		ComponentSets: make(map[string]interface{}),
 		GlobalVars:    types.GlobalVars{Styles: make(map[string]interface{})},
 	}
+	vars := newGlobalVars(simplifiedDesign.GlobalVars.Styles)
 
 	// 合并组件
 	for _, nodeWrapper := range apiResponse.Nodes {
+		if nodeWrapper == nil {
+			continue
+		}
 		if nodeWrapper.Components != nil {
 			for k, v := range nodeWrapper.Components {
 				simplifiedDesign.Components[k] = v
@@ -143,19 +238,30 @@ func parseFigmaNodeResponse(body io.Reader) (*types.SimplifiedDesign, error) {
 	}
 
 	// 解析节点
-	for _, nodeWrapper := range apiResponse.Nodes {
-		if isVisible(nodeWrapper.Document) {
-			if node := parseNode(nodeWrapper.Document, simplifiedDesign.GlobalVars.Styles, nil); node != nil {
-				simplifiedDesign.Nodes = append(simplifiedDesign.Nodes, *node)
-			}
+	requested := make(map[string]*types.SimplifiedNode, len(nodeIds))
+	for _, id := range nodeIds {
+		if _, seen := requested[id]; seen {
+			continue
+		}
+		requested[id] = nil
+
+		nodeWrapper := apiResponse.Nodes[id]
+		if nodeWrapper == nil || !isVisible(nodeWrapper.Document) {
+			continue
+		}
+		if node := parseNode(nodeWrapper.Document, vars, nil); node != nil {
+			simplifiedDesign.Nodes = append(simplifiedDesign.Nodes, *node)
+			requested[id] = node
 		}
 	}
 
-	return simplifiedDesign, nil
+	simplifiedDesign.DownloadCandidates = collectSVGCandidates(simplifiedDesign.Nodes)
+
+	return simplifiedDesign, requested, nil
 }
 
 // 简化的节点解析
-func parseNode(figmaNode types.FigmaNode, globalStyles map[string]interface{}, parent *types.FigmaNode) *types.SimplifiedNode {
+func parseNode(figmaNode types.FigmaNode, vars *globalVars, parent *types.FigmaNode) *types.SimplifiedNode {
 	simplified := &types.SimplifiedNode{
 		ID:   figmaNode.ID,
 		Name: figmaNode.Name,
@@ -169,24 +275,31 @@ func parseNode(figmaNode types.FigmaNode, globalStyles map[string]interface{}, p
 
 	// 处理样式
 	if len(figmaNode.Style) > 0 {
-		simplified.TextStyle = findOrCreateVar(globalStyles, figmaNode.Style, "style")
+		simplified.TextStyle = vars.findOrCreate(simplifyTextStyle(figmaNode.Style), "style")
+	}
+
+	// 处理富文本
+	if figmaNode.Characters != "" && (len(figmaNode.CharacterStyleOverrides) > 0 || figmaNode.Style["hyperlink"] != nil) {
+		if runs, runStyles := buildTextRuns(figmaNode, vars); runs != nil {
+			simplified.TextRuns = runs
+			simplified.Markdown = renderTextMarkdown(runs, runStyles, figmaNode.Style)
+		}
 	}
 
 	if len(figmaNode.Fills) > 0 {
-		simplified.Fills = findOrCreateVar(globalStyles, figmaNode.Fills, "fill")
+		simplified.Fills = vars.findOrCreate(figmaNode.Fills, "fill")
 	}
 
-	if len(figmaNode.Strokes) > 0 || figmaNode.StrokeWeight > 0 {
-		strokeData := map[string]interface{}{
-			"colors": figmaNode.Strokes,
-			"weight": figmaNode.StrokeWeight,
-			"align":  figmaNode.StrokeAlign,
+	if len(figmaNode.Strokes) > 0 {
+		if stroke := simplifyStrokes(figmaNode); stroke != nil {
+			simplified.Strokes = vars.findOrCreate(stroke, "stroke")
 		}
-		simplified.Strokes = findOrCreateVar(globalStyles, strokeData, "stroke")
 	}
 
 	if len(figmaNode.Effects) > 0 {
-		simplified.Effects = findOrCreateVar(globalStyles, figmaNode.Effects, "effect")
+		if effects := simplifyEffects(figmaNode); effects != (types.SimplifiedEffects{}) {
+			simplified.Effects = vars.findOrCreate(effects, "effect")
+		}
 	}
 
 	// 处理透明度
@@ -205,10 +318,7 @@ func parseNode(figmaNode types.FigmaNode, globalStyles map[string]interface{}, p
 
 	// 处理layout信息
 	if hasLayoutProperties(figmaNode) {
-		layoutData := buildLayoutData(figmaNode, parent)
-		if layoutJson, err := json.Marshal(layoutData); err == nil {
-			simplified.Layout = string(layoutJson)
-		}
+		simplified.Layout = vars.findOrCreate(buildLayoutData(figmaNode, parent), "layout")
 	}
 
 	// 处理组件ID
@@ -232,19 +342,22 @@ func parseNode(figmaNode types.FigmaNode, globalStyles map[string]interface{}, p
 		}
 	}
 
+	// 只由矢量图形组成的子树折叠为单个SVG节点，不再展开子节点
+	collapseToSVG := isVectorOnly(figmaNode)
+
 	// 递归处理子节点
-	if len(figmaNode.Children) > 0 {
+	if len(figmaNode.Children) > 0 && !collapseToSVG {
 		for _, child := range figmaNode.Children {
 			if isVisible(child) {
-				if childNode := parseNode(child, globalStyles, &figmaNode); childNode != nil {
+				if childNode := parseNode(child, vars, &figmaNode); childNode != nil {
 					simplified.Children = append(simplified.Children, *childNode)
 				}
 			}
 		}
 	}
 
-	// 转换VECTOR为IMAGE-SVG
-	if simplified.Type == "VECTOR" {
+	// 转换VECTOR和矢量图标为IMAGE-SVG
+	if simplified.Type == "VECTOR" || collapseToSVG {
 		simplified.Type = "IMAGE-SVG"
 	}
 
@@ -252,73 +365,76 @@ func parseNode(figmaNode types.FigmaNode, globalStyles map[string]interface{}, p
 }
 
 // 简化的layout数据构建
-func buildLayoutData(node types.FigmaNode, parent *types.FigmaNode) map[string]interface{} {
-	layout := make(map[string]interface{})
+func buildLayoutData(node types.FigmaNode, parent *types.FigmaNode) types.SimplifiedLayout {
+	layout := types.SimplifiedLayout{}
 
 	// 设置mode
 	if node.LayoutMode != "" {
-		layout["mode"] = strings.ToLower(node.LayoutMode)
+		layout.Mode = strings.ToLower(node.LayoutMode)
 	} else {
-		layout["mode"] = "none"
+		layout.Mode = "none"
 	}
 
 	// 对齐属性
 	if node.PrimaryAxisAlignItems != "" {
 		switch node.PrimaryAxisAlignItems {
 		case "MIN":
-			layout["justifyContent"] = "flex-start"
+			layout.JustifyContent = "flex-start"
 		case "CENTER":
-			layout["justifyContent"] = "center"
+			layout.JustifyContent = "center"
 		case "MAX":
-			layout["justifyContent"] = "flex-end"
+			layout.JustifyContent = "flex-end"
 		case "SPACE_BETWEEN":
-			layout["justifyContent"] = "space-between"
+			layout.JustifyContent = "space-between"
 		}
 	}
 
 	if node.CounterAxisAlignItems != "" {
 		switch node.CounterAxisAlignItems {
 		case "MIN":
-			layout["alignItems"] = "flex-start"
+			layout.AlignItems = "flex-start"
 		case "CENTER":
-			layout["alignItems"] = "center"
+			layout.AlignItems = "center"
 		case "MAX":
-			layout["alignItems"] = "flex-end"
+			layout.AlignItems = "flex-end"
 		case "BASELINE":
-			layout["alignItems"] = "baseline"
+			layout.AlignItems = "baseline"
 		}
 	}
 
 	if node.LayoutAlign != "" {
 		switch node.LayoutAlign {
 		case "INHERIT":
-			layout["alignSelf"] = "auto"
+			layout.AlignSelf = "auto"
 		case "MIN":
-			layout["alignSelf"] = "flex-start"
+			layout.AlignSelf = "flex-start"
 		case "CENTER":
-			layout["alignSelf"] = "center"
+			layout.AlignSelf = "center"
 		case "MAX":
-			layout["alignSelf"] = "flex-end"
+			layout.AlignSelf = "flex-end"
 		case "STRETCH":
-			layout["alignSelf"] = "stretch"
+			layout.AlignSelf = "stretch"
 		}
 	}
 
 	if node.LayoutWrap != "" {
-		layout["wrap"] = node.LayoutWrap == "WRAP"
+		wrap := node.LayoutWrap == "WRAP"
+		layout.Wrap = &wrap
 	}
 
 	if node.ItemSpacing != nil {
-		layout["gap"] = fmt.Sprintf("%.0fpx", *node.ItemSpacing)
+		layout.Gap = fmt.Sprintf("%.0fpx", *node.ItemSpacing)
 	}
 
-	// bbox和尺寸
+	// bbox和尺寸，位置换算为相对父节点的偏移
 	if node.AbsoluteBoundingBox != nil {
-		layout["locationRelativeToParent"] = map[string]interface{}{
-			"x": node.AbsoluteBoundingBox.X,
-			"y": node.AbsoluteBoundingBox.Y,
+		if parent != nil && parent.AbsoluteBoundingBox != nil {
+			layout.LocationRelativeToParent = map[string]interface{}{
+				"x": roundValue(node.AbsoluteBoundingBox.X - parent.AbsoluteBoundingBox.X),
+				"y": roundValue(node.AbsoluteBoundingBox.Y - parent.AbsoluteBoundingBox.Y),
+			}
 		}
-		layout["dimensions"] = map[string]interface{}{
+		layout.Dimensions = map[string]interface{}{
 			"width":  node.AbsoluteBoundingBox.Width,
 			"height": node.AbsoluteBoundingBox.Height,
 		}
@@ -328,9 +444,9 @@ func buildLayoutData(node types.FigmaNode, parent *types.FigmaNode) map[string]i
 	if node.PaddingTop != nil || node.PaddingRight != nil || node.PaddingBottom != nil || node.PaddingLeft != nil {
 		if node.PaddingTop != nil && node.PaddingRight != nil && node.PaddingBottom != nil && node.PaddingLeft != nil {
 			if *node.PaddingTop == *node.PaddingRight && *node.PaddingRight == *node.PaddingBottom && *node.PaddingBottom == *node.PaddingLeft {
-				layout["padding"] = fmt.Sprintf("%.0fpx", *node.PaddingTop)
+				layout.Padding = fmt.Sprintf("%.0fpx", *node.PaddingTop)
 			} else {
-				layout["padding"] = fmt.Sprintf("%.0fpx %.0fpx %.0fpx %.0fpx",
+				layout.Padding = fmt.Sprintf("%.0fpx %.0fpx %.0fpx %.0fpx",
 					*node.PaddingTop, *node.PaddingRight, *node.PaddingBottom, *node.PaddingLeft)
 			}
 		}
@@ -345,26 +461,41 @@ func buildLayoutData(node types.FigmaNode, parent *types.FigmaNode) map[string]i
 		if node.LayoutSizingVertical != "" {
 			sizing["vertical"] = strings.ToLower(node.LayoutSizingVertical)
 		}
-		layout["sizing"] = sizing
+		layout.Sizing = sizing
 	}
 
+	applyAutoLayoutExtras(&layout, node, parent)
+
 	if len(node.OverflowDirection) > 0 {
 		var scrollDirs []string
 		for _, dir := range node.OverflowDirection {
 			scrollDirs = append(scrollDirs, strings.ToLower(dir))
 		}
-		layout["overflowScroll"] = scrollDirs
+		layout.OverflowScroll = scrollDirs
 	}
 
 	if node.LayoutPositioning != "" {
 		switch node.LayoutPositioning {
 		case "AUTO":
-			layout["position"] = "relative"
+			layout.Position = "relative"
 		case "ABSOLUTE":
-			layout["position"] = "absolute"
+			layout.Position = "absolute"
 		}
 	}
 
+	// 非auto-layout框架中的子节点按约束绝对定位
+	if parent != nil && isFreeformFrame(*parent) {
+		layout.Position = "absolute"
+	}
+
+	if layout.Position == "absolute" && parent != nil {
+		layout.Top, layout.Right, layout.Bottom, layout.Left = absoluteInsets(node, *parent)
+		applyConstraintHints(&layout, node, *parent)
+	} else if isFreeformFrame(node) && len(node.Children) > 0 {
+		// 作为绝对定位子节点的包含块
+		layout.Position = "relative"
+	}
+
 	return layout
 }
 
@@ -385,6 +516,16 @@ func hasLayoutProperties(node types.FigmaNode) bool {
 		node.LayoutPositioning != "" ||
 		node.LayoutGrow != nil ||
 		len(node.OverflowDirection) > 0 ||
+		node.Constraints != nil ||
+		node.MinWidth != nil ||
+		node.MaxWidth != nil ||
+		node.MinHeight != nil ||
+		node.MaxHeight != nil ||
+		node.CounterAxisSpacing != nil ||
+		node.GridRowCount > 0 ||
+		node.GridColumnCount > 0 ||
+		node.GridRowSpan != nil ||
+		node.GridColumnSpan != nil ||
 		node.AbsoluteBoundingBox != nil
 }
 
@@ -396,26 +537,30 @@ func isVisible(node types.FigmaNode) bool {
 	return true
 }
 
-// 简化的变量查找/创建
-func findOrCreateVar(globalStyles map[string]interface{}, value interface{}, prefix string) string {
-	// 检查是否已存在相同的值
-	valueJson, _ := json.Marshal(value)
-	valueStr := string(valueJson)
+// globalVars 一次解析中的全局样式变量，按值的JSON建立索引，查找相同的值不需要遍历已有变量
+type globalVars struct {
+	styles map[string]interface{}
+	ids    map[string]string
+}
 
-	for existingId, existingValue := range globalStyles {
-		existingJson, _ := json.Marshal(existingValue)
-		if string(existingJson) == valueStr {
-			return existingId
-		}
+func newGlobalVars(styles map[string]interface{}) *globalVars {
+	return &globalVars{styles: styles, ids: make(map[string]string)}
+}
+
+// findOrCreate 返回与value相同的已有变量ID，没有时创建新变量
+func (g *globalVars) findOrCreate(value interface{}, prefix string) string {
+	valueJson, _ := json.Marshal(value)
+	if varId, ok := g.ids[string(valueJson)]; ok {
+		return varId
 	}
 
 	// 创建新的变量ID
-	varId := generateVarId(globalStyles, prefix)
-	globalStyles[varId] = value
+	varId := generateVarId(g.styles, prefix)
+	g.styles[varId] = value
+	g.ids[string(valueJson)] = varId
 	return varId
 }
 
-// 简化的变量ID生成
 func generateVarId(globalStyles map[string]interface{}, prefix string) string {
 	chars := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
 
@@ -430,218 +575,3 @@ func generateVarId(globalStyles map[string]interface{}, prefix string) string {
 		}
 	}
 }
-
-// DownloadFigmaImages 简化的图片下载
-func DownloadFigmaImages(figmaApiKey, fileKey string, nodes []interface{}, localPath string, pngScale float64, svgOptions map[string]interface{}) error {
-	// 解析节点列表
-	var imageNodes []types.ImageNode
-	for _, node := range nodes {
-		if nodeMap, ok := node.(map[string]interface{}); ok {
-			imageNode := types.ImageNode{}
-
-			if nodeId, exists := nodeMap["nodeId"].(string); exists {
-				imageNode.NodeId = nodeId
-			} else {
-				continue
-			}
-
-			if imageRef, exists := nodeMap["imageRef"].(string); exists {
-				imageNode.ImageRef = imageRef
-			}
-
-			if fileName, exists := nodeMap["fileName"].(string); exists {
-				imageNode.FileName = fileName
-			} else {
-				continue
-			}
-
-			imageNodes = append(imageNodes, imageNode)
-		}
-	}
-
-	if len(imageNodes) == 0 {
-		return fmt.Errorf("没有有效的图像节点")
-	}
-
-	// 创建本地目录
-	if err := os.MkdirAll(localPath, 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %v", err)
-	}
-
-	// 分离不同类型的节点
-	var svgNodes, pngNodes, imageRefNodes []types.ImageNode
-
-	for _, node := range imageNodes {
-		if node.ImageRef != "" {
-			imageRefNodes = append(imageRefNodes, node)
-		} else if strings.HasSuffix(strings.ToLower(node.FileName), ".svg") {
-			svgNodes = append(svgNodes, node)
-		} else {
-			pngNodes = append(pngNodes, node)
-		}
-	}
-
-	// 简单的顺序下载
-	if len(svgNodes) > 0 {
-		if err := downloadImages(figmaApiKey, fileKey, svgNodes, localPath, "svg", svgOptions, 1.0); err != nil {
-			return fmt.Errorf("下载SVG图像失败: %v", err)
-		}
-	}
-
-	if len(pngNodes) > 0 {
-		if err := downloadImages(figmaApiKey, fileKey, pngNodes, localPath, "png", nil, pngScale); err != nil {
-			return fmt.Errorf("下载PNG图像失败: %v", err)
-		}
-	}
-
-	if len(imageRefNodes) > 0 {
-		if err := downloadImageFills(figmaApiKey, fileKey, imageRefNodes, localPath); err != nil {
-			return fmt.Errorf("下载ImageRef图像失败: %v", err)
-		}
-	}
-
-	fmt.Printf("成功下载 %d 个图像到: %s\n", len(imageNodes), localPath)
-	return nil
-}
-
-// downloadImages 简化的图片下载
-func downloadImages(figmaApiKey, fileKey string, nodes []types.ImageNode, localPath, format string, options map[string]interface{}, scale float64) error {
-	var nodeIds []string
-	for _, node := range nodes {
-		nodeIds = append(nodeIds, node.NodeId)
-	}
-
-	apiURL := fmt.Sprintf("https://api.figma.com/v1/images/%s", fileKey)
-	params := url.Values{}
-	params.Add("ids", strings.Join(nodeIds, ","))
-	params.Add("format", format)
-
-	if format == "png" && scale != 1.0 {
-		params.Add("scale", strconv.FormatFloat(scale, 'f', 1, 64))
-	}
-
-	if format == "svg" && options != nil {
-		if outlineText, ok := options["outlineText"].(bool); ok && outlineText {
-			params.Add("svg_outline_text", "true")
-		}
-		if includeId, ok := options["includeId"].(bool); ok && includeId {
-			params.Add("svg_include_id", "true")
-		}
-		if simplifyStroke, ok := options["simplifyStroke"].(bool); ok && simplifyStroke {
-			params.Add("svg_simplify_stroke", "true")
-		}
-	}
-
-	fullURL := apiURL + "?" + params.Encode()
-
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Add("X-FIGMA-TOKEN", figmaApiKey)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Figma API错误: %d", resp.StatusCode)
-	}
-
-	var imagesResp types.FigmaImagesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&imagesResp); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	if imagesResp.Error != "" {
-		return fmt.Errorf("Figma API error: %s", imagesResp.Error)
-	}
-
-	// 顺序下载文件
-	for _, node := range nodes {
-		imageURL, exists := imagesResp.Images[node.NodeId]
-		if !exists || imageURL == "" {
-			fmt.Printf("警告: 节点 %s 没有找到有效的图像URL\n", node.NodeId)
-			continue
-		}
-
-		if err := downloadFile(imageURL, filepath.Join(localPath, node.FileName)); err != nil {
-			fmt.Printf("警告: 下载文件 %s 失败: %v\n", node.FileName, err)
-		} else {
-			fmt.Printf("成功下载: %s\n", node.FileName)
-		}
-	}
-
-	return nil
-}
-
-// downloadImageFills 简化的图像填充下载
-func downloadImageFills(figmaApiKey, fileKey string, nodes []types.ImageNode, localPath string) error {
-	apiURL := fmt.Sprintf("https://api.figma.com/v1/files/%s/images", fileKey)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Add("X-FIGMA-TOKEN", figmaApiKey)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Figma API错误: %d", resp.StatusCode)
-	}
-
-	var fillsResp types.FigmaImageFillsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&fillsResp); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	if fillsResp.Error != "" {
-		return fmt.Errorf("Figma API error: %s", fillsResp.Error)
-	}
-
-	// 顺序下载文件
-	for _, node := range nodes {
-		imageURL, exists := fillsResp.Meta.Images[node.ImageRef]
-		if !exists || imageURL == "" {
-			fmt.Printf("警告: ImageRef %s 没有找到有效的图像URL\n", node.ImageRef)
-			continue
-		}
-
-		if err := downloadFile(imageURL, filepath.Join(localPath, node.FileName)); err != nil {
-			fmt.Printf("警告: 下载文件 %s 失败: %v\n", node.FileName, err)
-		} else {
-			fmt.Printf("成功下载: %s\n", node.FileName)
-		}
-	}
-
-	return nil
-}
-
-// downloadFile 简化的文件下载
-func downloadFile(url, filepath string) error {
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
-	}
-
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
-}
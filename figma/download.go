@@ -0,0 +1,783 @@
+package figma
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"figma-mcp-server/types"
+)
+
+const (
+	defaultDownloadConcurrency = 8
+	maxDownloadConcurrency     = 32
+	defaultFileTimeout         = 60 * time.Second
+	defaultImageBatchSize      = 50
+	maxImageRequestRetries     = 2
+	// maxRetryAfter 429响应要求等待超过该时间时不再重试
+	maxRetryAfter = 60 * time.Second
+	// maxDownloadBytes 单个文件的大小上限
+	maxDownloadBytes = 200 << 20
+)
+
+var (
+	// 文件下载客户端，单个文件的超时由context控制
+	downloadClient = &http.Client{}
+)
+
+// ProgressFunc 下载进度回调，completed为已处理的文件数
+type ProgressFunc func(completed, total int, message string)
+
+// DownloadOptions 图片下载选项
+type DownloadOptions struct {
+	PngScale   float64
+	SvgOptions map[string]interface{}
+	// PngScales 多倍图导出，设置后代替PngScale，同样适用于JPG
+	PngScales []float64
+	// ScaleLayout 多倍图的命名方式：suffix(name@2x.png)或android(drawable-xxhdpi/name.png)
+	ScaleLayout string
+	// UseExportSettings 按节点在Figma中配置的导出设置生成文件
+	UseExportSettings bool
+	// Concurrency 同时下载的文件数，0使用默认值
+	Concurrency int
+	// FileTimeout 单个文件的下载超时，0使用默认值
+	FileTimeout time.Duration
+	// BatchSize 每次/v1/images请求包含的节点数，0使用默认值
+	BatchSize int
+	// Progress 每个文件处理完成后调用，可以为nil
+	Progress ProgressFunc
+	// Overwrite 目标文件已存在时的处理：overwrite(默认)、skip或rename
+	Overwrite string
+	// Inline 在结果中直接返回文件内容，此时localPath可以为空(不写磁盘)
+	Inline bool
+	// MaxInlineBytes 单个文件内联的大小上限，0使用默认值
+	MaxInlineBytes int
+	// Archive 保留文件内容用于打包，此时localPath可以为空
+	Archive bool
+	// Sprite 把下载的SVG合并为<symbol>雪碧图，值为雪碧图的文件名
+	Sprite string
+	// Components 为每个SVG生成图标组件：react、vue或svelte
+	Components string
+	// ComponentDir 组件和index.ts所在的目录，相对于localPath
+	ComponentDir string
+}
+
+// downloadTask 一个输出文件：导出任务、下载地址和最终结果
+type downloadTask struct {
+	job    exportJob
+	url    string
+	path   string
+	result types.DownloadResult
+	// keepData 下载后保留文件内容用于内联返回或打包
+	keepData bool
+	// svgOptimize SVG后处理选项，nil表示保持Figma的原始输出
+	svgOptimize *svgOptimizeOptions
+}
+
+// DownloadFigmaImages 下载图片：先并发获取各组的渲染地址，再用有上限的worker池下载文件
+// 每个输出文件都有独立的结果，只有全部文件都失败时才返回错误
+func DownloadFigmaImages(figmaApiKey, fileKey string, nodes []interface{}, localPath string, options DownloadOptions) (*types.DownloadReport, error) {
+	// 解析节点列表，缺少必需字段的节点记为失败并在报告中说明
+	var imageNodes []types.ImageNode
+	var invalid []exportJob
+	for i, node := range nodes {
+		if nodeMap, ok := node.(map[string]interface{}); ok {
+			imageNode := types.ImageNode{}
+
+			if nodeId, exists := nodeMap["nodeId"].(string); exists {
+				// 接受链接中的12-345格式
+				if normalized, err := NormalizeNodeId(nodeId); err == nil {
+					nodeId = normalized
+				}
+				imageNode.NodeId = nodeId
+			} else {
+				invalid = append(invalid, exportJob{fail: fmt.Sprintf("nodes[%d]缺少nodeId", i)})
+				continue
+			}
+
+			if imageRef, exists := nodeMap["imageRef"].(string); exists {
+				imageNode.ImageRef = imageRef
+			}
+
+			if format, exists := nodeMap["format"].(string); exists {
+				imageNode.Format = format
+			}
+
+			if scale, exists := nodeMap["scale"].(float64); exists && scale > 0 {
+				imageNode.Scale = scale
+			}
+
+			// 使用导出设置时文件名可以省略，由节点名和后缀生成
+			if fileName, exists := nodeMap["fileName"].(string); exists {
+				imageNode.FileName = fileName
+			} else if !options.UseExportSettings || imageNode.ImageRef != "" {
+				invalid = append(invalid, exportJob{node: imageNode, fail: fmt.Sprintf("nodes[%d]缺少fileName", i)})
+				continue
+			}
+
+			imageNodes = append(imageNodes, imageNode)
+		} else {
+			invalid = append(invalid, exportJob{fail: fmt.Sprintf("nodes[%d]不是对象", i)})
+		}
+	}
+
+	if len(imageNodes) == 0 {
+		if len(invalid) > 0 {
+			return nil, fmt.Errorf("没有有效的图像节点: %s", invalid[0].fail)
+		}
+		return nil, fmt.Errorf("没有有效的图像节点")
+	}
+
+	if options.ScaleLayout == "android" {
+		for _, scale := range options.PngScales {
+			if _, ok := androidDensities[scale]; !ok {
+				return nil, fmt.Errorf("缩放比例 %g 没有对应的Android密度目录", scale)
+			}
+		}
+	}
+
+	switch options.Overwrite {
+	case "":
+		options.Overwrite = OverwriteReplace
+	case OverwriteReplace, OverwriteSkip, OverwriteRename:
+	default:
+		return nil, fmt.Errorf("不支持的覆盖策略: %s", options.Overwrite)
+	}
+
+	if localPath == "" && !options.Inline && !options.Archive {
+		return nil, fmt.Errorf("缺少localPath，或者需要设置inline或archive直接返回文件内容")
+	}
+
+	generateIcons := options.Sprite != "" || options.Components != ""
+	if options.Components != "" && componentExtensions[options.Components] == "" {
+		return nil, fmt.Errorf("不支持的组件类型: %s", options.Components)
+	}
+	if generateIcons && localPath == "" && !options.Archive {
+		return nil, fmt.Errorf("生成雪碧图或组件需要localPath或archive")
+	}
+
+	// 创建本地目录，目录必须位于允许的下载根目录内
+	var dir string
+	var err error
+	if localPath != "" {
+		if dir, err = resolveDownloadDir(localPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// 分离需要渲染的节点和图片填充
+	var renderNodes []types.ImageNode
+	var jobs []exportJob
+
+	for _, node := range imageNodes {
+		if node.ImageRef != "" {
+			jobs = append(jobs, exportJob{node: node})
+		} else {
+			renderNodes = append(renderNodes, node)
+		}
+	}
+
+	var renderJobs []exportJob
+	if options.UseExportSettings && len(renderNodes) > 0 {
+		if renderJobs, err = exportSettingJobs(figmaApiKey, fileKey, renderNodes, options); err != nil {
+			return nil, fmt.Errorf("读取导出设置失败: %v", err)
+		}
+	} else {
+		renderJobs = defaultExportJobs(renderNodes, options)
+	}
+	jobs = append(renderJobs, jobs...)
+	jobs = append(jobs, invalid...)
+
+	svgOptimize := parseSVGOptimizeOptions(options.SvgOptions)
+
+	tasks := make([]*downloadTask, len(jobs))
+	for i, job := range jobs {
+		tasks[i] = &downloadTask{
+			job:      job,
+			keepData: options.Inline || options.Archive,
+			result: types.DownloadResult{
+				NodeId:   job.node.NodeId,
+				ImageRef: job.node.ImageRef,
+				FileName: job.node.FileName,
+				Format:   job.format,
+			},
+		}
+		if job.format == "svg" {
+			tasks[i].svgOptimize = svgOptimize
+		}
+		if job.skip != "" {
+			tasks[i].result.Status = "skipped"
+			tasks[i].result.Error = job.skip
+		}
+		if job.fail != "" {
+			tasks[i].result.Status = "failed"
+			tasks[i].result.Error = job.fail
+		}
+	}
+
+	// 下载到磁盘时读取清单和文件版本，来源版本和内容都未变的文件直接跳过
+	var manifest *types.DownloadManifest
+	var version fileVersion
+	var nodeNames map[string]string
+	var index *contentIndex
+	if dir != "" {
+		manifest = loadManifest(dir, fileKey)
+		index = newContentIndex()
+	}
+
+	var renderIds []string
+	for _, node := range renderNodes {
+		renderIds = append(renderIds, node.NodeId)
+	}
+	var warnings []string
+	if len(renderIds) > 0 && (dir != "" || generateIcons) {
+		// 读取失败时只是无法跳过未变化的渲染文件，组件名退回到文件名，原因记录在报告中
+		if version, nodeNames, err = fetchNodeInfo(figmaApiKey, fileKey, renderIds, options.BatchSize); err != nil {
+			warnings = append(warnings, fmt.Sprintf("读取文件版本和节点名称失败，未变化的渲染文件会重新下载: %v", err))
+		}
+	}
+
+	// 在请求地址之前确定输出路径，已存在而被跳过的文件不会再下载
+	// 不落盘时同样规范化文件名，保证内联和打包中的名称安全且唯一
+	paths := newOutputPaths(dir, options.Overwrite)
+	for _, task := range tasks {
+		if task.result.Status != "" {
+			continue
+		}
+		// 需要文件内容(内联或打包)时不能跳过下载
+		if name, err := safeFileName(task.result.FileName); err == nil && dir != "" && !task.keepData {
+			key := manifestKey(manifest, filepath.ToSlash(name), task.job.node.ImageRef)
+			name = filepath.FromSlash(key)
+			if entry, ok := manifest.Files[key]; ok && !paths.reserved[name] && unchangedEntry(dir, name, task, entry, version) {
+				paths.reserved[name] = true
+				markUnchanged(task, dir, key, entry)
+				if entry.DuplicateOf == "" {
+					index.claim(entry.SHA256, task)
+				}
+				continue
+			}
+		}
+		name, exists, err := paths.reserve(task.result.FileName)
+		switch {
+		case err != nil:
+			task.result.Status = "failed"
+			task.result.Error = err.Error()
+		case exists:
+			task.result.Status = "skipped"
+			task.result.Error = "文件已存在"
+			task.result.Path = filepath.Join(dir, name)
+		default:
+			task.result.FileName = name
+			if dir != "" {
+				task.path = filepath.Join(dir, name)
+			}
+		}
+	}
+
+	resolveDownloadURLs(figmaApiKey, fileKey, tasks, options)
+
+	var pending []*downloadTask
+	for _, task := range tasks {
+		if task.url != "" {
+			pending = append(pending, task)
+		}
+	}
+
+	if options.Progress != nil {
+		options.Progress(0, len(pending), "已获取图像地址，开始下载")
+	}
+
+	runDownloads(pending, index, paths, options)
+
+	if options.Inline {
+		inlineResults(tasks, options.MaxInlineBytes)
+	}
+
+	report := &types.DownloadReport{LocalPath: dir, Total: len(tasks), Warnings: warnings}
+	if generateIcons {
+		if report.Generated, err = generateIconFiles(tasks, nodeNames, options, paths); err != nil {
+			return nil, fmt.Errorf("生成雪碧图或组件失败: %v", err)
+		}
+	}
+	if manifest != nil {
+		updateManifest(manifest, tasks, version)
+		if err := saveManifest(dir, manifest); err != nil {
+			return nil, fmt.Errorf("写入下载清单失败: %v", err)
+		}
+		report.Manifest = manifest
+	}
+
+	for _, task := range tasks {
+		switch task.result.Status {
+		case "downloaded":
+			report.Downloaded++
+		case "unchanged":
+			report.Unchanged++
+		case "duplicate":
+			report.Duplicates++
+		case "skipped":
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Results = append(report.Results, task.result)
+	}
+
+	if report.Downloaded+report.Unchanged+report.Duplicates == 0 && report.Failed > 0 {
+		return report, fmt.Errorf("全部 %d 个文件下载失败", report.Failed)
+	}
+	return report, nil
+}
+
+// resolveDownloadURLs 并发请求每组的渲染地址和图片填充地址，
+// 拿不到地址的任务直接标记为失败
+func resolveDownloadURLs(figmaApiKey, fileKey string, tasks []*downloadTask, options DownloadOptions) {
+	var wg sync.WaitGroup
+
+	for _, group := range groupDownloadTasks(tasks) {
+		wg.Add(1)
+		go func(group exportGroup) {
+			defer wg.Done()
+
+			images, failures := requestImageURLs(figmaApiKey, fileKey, group, options)
+			for _, task := range group.tasks {
+				assignURL(task, images[task.job.node.NodeId], failures[task.job.node.NodeId])
+			}
+		}(group)
+	}
+
+	var fillTasks []*downloadTask
+	for _, task := range tasks {
+		if task.result.Status == "" && task.job.node.ImageRef != "" {
+			fillTasks = append(fillTasks, task)
+		}
+	}
+	if len(fillTasks) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			images, err := requestImageFillURLs(figmaApiKey, fileKey)
+			for _, task := range fillTasks {
+				assignURL(task, images[task.job.node.ImageRef], err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// markUnchanged 按清单记录未变化的文件，不再下载
+func markUnchanged(task *downloadTask, dir, name string, entry types.ManifestEntry) {
+	task.path = filepath.Join(dir, name)
+	task.result.FileName = name
+	task.result.Status = "unchanged"
+	task.result.Path = task.path
+	task.result.Bytes = entry.Bytes
+	task.result.SHA256 = entry.SHA256
+	task.result.DuplicateOf = entry.DuplicateOf
+	if entry.Format != "" {
+		task.result.Format = entry.Format
+	}
+}
+
+// assignURL 记录任务的下载地址，或者记录获取地址失败的原因
+func assignURL(task *downloadTask, imageURL string, err error) {
+	switch {
+	case err != nil:
+		task.result.Status = "failed"
+		task.result.Error = fmt.Sprintf("获取图像地址失败: %v", err)
+	case imageURL == "":
+		task.result.Status = "failed"
+		task.result.Error = "没有找到有效的图像URL"
+	default:
+		task.url = imageURL
+	}
+}
+
+// runDownloads 使用有上限的worker池下载文件，结果写回各个任务
+// 写入磁盘时通过index去掉内容相同的文件，index为nil时不去重
+func runDownloads(tasks []*downloadTask, index *contentIndex, paths *outputPaths, options DownloadOptions) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+	if concurrency > maxDownloadConcurrency {
+		concurrency = maxDownloadConcurrency
+	}
+
+	timeout := options.FileTimeout
+	if timeout <= 0 {
+		timeout = defaultFileTimeout
+	}
+
+	taskCh := make(chan *downloadTask)
+	var mu sync.Mutex
+	completed := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskCh {
+				saveDownload(task, index, paths, timeout)
+
+				// 进度回调在锁内串行执行，调用方无需自行加锁
+				if options.Progress != nil {
+					mu.Lock()
+					completed++
+					options.Progress(completed, len(tasks), task.result.FileName)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		taskCh <- task
+	}
+	close(taskCh)
+	wg.Wait()
+}
+
+// saveDownload 下载一个文件并写入磁盘，记录路径、大小、格式和尺寸
+// 只写入磁盘的文件边下载边计算哈希并写入临时文件，需要内容(内联、打包或SVG优化)时才读入内存
+func saveDownload(task *downloadTask, index *contentIndex, paths *outputPaths, timeout time.Duration) {
+	result := &task.result
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	file, err := fetchFile(ctx, task, filepath.Dir(task.path))
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return
+	}
+	defer file.discard()
+
+	if task.job.node.ImageRef != "" {
+		if done := fixFillExtension(task, sniffFormat(file.head), paths); done {
+			return
+		}
+	}
+
+	result.SHA256 = file.sha256
+	result.Status = "downloaded"
+	result.Path = task.path
+
+	// 只内联返回或打包时没有输出路径，文件不落盘
+	if task.path != "" {
+		first := index.claim(result.SHA256, task)
+		if fileHash(task.path) == result.SHA256 {
+			result.Status = "unchanged"
+		} else if err := file.commit(task.path); err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			return
+		}
+		if first != nil {
+			// 与同批次的另一个文件内容相同，请求的文件照常写入，duplicateOf指向先写入的文件
+			result.Status = "duplicate"
+			result.DuplicateOf = first.result.FileName
+		}
+	}
+
+	if task.keepData {
+		result.Data = file.data
+	}
+	result.Bytes = file.size
+	if result.Format == "" {
+		result.Format = sniffFormat(file.head)
+	}
+	result.Width, result.Height = file.dimensions(result.Path, result.Format)
+}
+
+// fixFillExtension 图片填充按原始字节写入，扩展名与实际格式不一致(或没有扩展名)时更正文件名
+// 更正后的文件名已存在且策略为skip，或者分配失败时记录结果并返回true
+func fixFillExtension(task *downloadTask, format string, paths *outputPaths) bool {
+	result := &task.result
+	if format == "" {
+		return false
+	}
+	result.Format = format
+
+	if extensionFormat(path.Ext(result.FileName)) == format {
+		return false
+	}
+
+	// 从请求的文件名重新分配，rename策略会按新的扩展名检查冲突
+	requested := task.job.node.FileName
+	name, exists, err := paths.reserve(strings.TrimSuffix(requested, path.Ext(requested)) + "." + format)
+	switch {
+	case err != nil:
+		result.Status = "failed"
+		result.Error = err.Error()
+		return true
+	case exists:
+		result.FileName = name
+		result.Status = "skipped"
+		result.Error = "文件已存在"
+		result.Path = filepath.Join(paths.dir, name)
+		return true
+	}
+
+	result.FileName = name
+	if task.path != "" {
+		task.path = filepath.Join(paths.dir, name)
+	}
+	return false
+}
+
+// extensionFormat 将扩展名转换为sniffFormat使用的格式名
+func extensionFormat(ext string) string {
+	format := strings.ToLower(strings.TrimPrefix(ext, "."))
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
+
+// requestImageURLs 请求一组节点的渲染地址，返回节点ID到地址的映射和失败的原因
+func requestImageURLs(figmaApiKey, fileKey string, group exportGroup, options DownloadOptions) (map[string]string, map[string]error) {
+	var nodeIds []string
+	for _, task := range group.tasks {
+		nodeIds = append(nodeIds, task.job.node.NodeId)
+	}
+
+	params := url.Values{}
+	params.Add("format", group.format)
+
+	if (group.format == "png" || group.format == "jpg") && group.scale != 1.0 {
+		params.Add("scale", strconv.FormatFloat(group.scale, 'f', -1, 64))
+	}
+
+	if svgOptions := options.SvgOptions; group.format == "svg" && svgOptions != nil {
+		if outlineText, ok := svgOptions["outlineText"].(bool); ok && outlineText {
+			params.Add("svg_outline_text", "true")
+		}
+		if includeId, ok := svgOptions["includeId"].(bool); ok && includeId {
+			params.Add("svg_include_id", "true")
+		}
+		if simplifyStroke, ok := svgOptions["simplifyStroke"].(bool); ok && simplifyStroke {
+			params.Add("svg_simplify_stroke", "true")
+		}
+	}
+
+	return fetchImageURLs(figmaApiKey, fileKey, nodeIds, params, options.BatchSize)
+}
+
+// fetchImageURLs 将节点ID分批请求/v1/images并合并结果
+// 请求失败或没有返回地址的ID会缩小批次后重试，已成功的ID不会重复请求；
+// 最终失败的ID在failures中记录各自的错误，只是没有返回地址的ID错误为nil
+func fetchImageURLs(figmaApiKey, fileKey string, nodeIds []string, params url.Values, batchSize int) (map[string]string, map[string]error) {
+	images := make(map[string]string)
+	failures := requestInBatches(nodeIds, batchSize, func(batch []string) ([]string, error) {
+		batchImages, err := requestImageBatch(figmaApiKey, fileKey, batch, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var done []string
+		for _, id := range batch {
+			if imageURL := batchImages[id]; imageURL != "" {
+				images[id] = imageURL
+				done = append(done, id)
+			}
+		}
+		return done, nil
+	})
+
+	return images, failures
+}
+
+// requestInBatches 去重后按batchSize分批调用request，避免ids参数过长
+// request返回本批中已得到结果的ID，请求失败或没有结果的ID会缩小批次后重试：
+//   - 认证失败、文件不存在等不可重试的4xx错误立即停止，剩余的ID都记为该错误
+//   - 429按Retry-After等待后重试，不缩小批次，等待时间过长时停止
+//
+// 返回最终没有得到结果的ID及其最后一次的错误(只是没有结果时为nil)
+func requestInBatches(nodeIds []string, batchSize int, request func(batch []string) ([]string, error)) map[string]error {
+	if batchSize <= 0 {
+		batchSize = defaultImageBatchSize
+	}
+
+	seen := make(map[string]bool)
+	var pending []string
+	for _, id := range nodeIds {
+		if !seen[id] {
+			seen[id] = true
+			pending = append(pending, id)
+		}
+	}
+
+	failures := make(map[string]error)
+	fail := func(ids []string, err error) {
+		for _, id := range ids {
+			failures[id] = err
+		}
+	}
+
+	var wait time.Duration
+	shrink := false
+	for attempt := 0; attempt <= maxImageRequestRetries && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if wait == 0 {
+				wait = time.Duration(attempt) * time.Second
+			}
+			time.Sleep(wait)
+			if shrink {
+				batchSize = max(1, batchSize/2)
+			}
+		}
+		wait, shrink = 0, false
+
+		var failed []string
+		for start := 0; start < len(pending); start += batchSize {
+			batch := pending[start:min(start+batchSize, len(pending))]
+
+			done, err := request(batch)
+			if err != nil {
+				fail(batch, err)
+				failed = append(failed, batch...)
+
+				var apiErr *apiError
+				if !errors.As(err, &apiErr) || apiErr.retryable() {
+					shrink = true
+					continue
+				}
+				// 其余批次不再请求，同样记为该错误
+				rest := pending[min(start+batchSize, len(pending)):]
+				fail(rest, err)
+				if apiErr.statusCode != http.StatusTooManyRequests || apiErr.retryAfter > maxRetryAfter {
+					return failures
+				}
+				wait = max(wait, apiErr.retryAfter)
+				failed = append(failed, rest...)
+				break
+			}
+
+			finished := make(map[string]bool, len(done))
+			for _, id := range done {
+				finished[id] = true
+			}
+			for _, id := range batch {
+				if finished[id] {
+					delete(failures, id)
+				} else {
+					failures[id] = nil
+					failed = append(failed, id)
+					shrink = true
+				}
+			}
+		}
+		pending = failed
+	}
+
+	return failures
+}
+
+// requestImageBatch 发送一次/v1/images请求
+func requestImageBatch(figmaApiKey, fileKey string, nodeIds []string, params url.Values) (map[string]string, error) {
+	batchParams := url.Values{}
+	for k, v := range params {
+		batchParams[k] = v
+	}
+	batchParams.Set("ids", strings.Join(nodeIds, ","))
+
+	apiURL := fmt.Sprintf("https://api.figma.com/v1/images/%s?%s", fileKey, batchParams.Encode())
+	resp, err := figmaGet(figmaApiKey, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var imagesResp types.FigmaImagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&imagesResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if imagesResp.Error != "" {
+		return nil, fmt.Errorf("Figma API error: %s", imagesResp.Error)
+	}
+
+	return imagesResp.Images, nil
+}
+
+// requestImageFillURLs 请求图片填充的原图地址，返回imageRef到地址的映射
+func requestImageFillURLs(figmaApiKey, fileKey string) (map[string]string, error) {
+	resp, err := figmaGet(figmaApiKey, fmt.Sprintf("https://api.figma.com/v1/files/%s/images", fileKey))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fillsResp types.FigmaImageFillsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fillsResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if fillsResp.Error != "" {
+		return nil, fmt.Errorf("Figma API error: %s", fillsResp.Error)
+	}
+
+	return fillsResp.Meta.Images, nil
+}
+
+// fetchFile 下载单个文件，超过ctx的期限后取消，超过maxDownloadBytes视为失败
+// 只写入磁盘且不需要SVG优化时流式写入dir中的临时文件，否则读入内存并完成SVG优化
+func fetchFile(ctx context.Context, task *downloadTask, dir string) (*stagedFile, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", task.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, maxDownloadBytes+1)
+	if task.path != "" && !task.keepData && task.svgOptimize == nil {
+		return stageToDisk(body, dir)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadBytes {
+		return nil, errFileTooLarge
+	}
+
+	if task.svgOptimize != nil {
+		if optimized, err := optimizeSVG(data, task.svgOptimize); err == nil {
+			data = optimized
+		} else {
+			task.result.Error = fmt.Sprintf("SVG优化失败，保留原始文件: %v", err)
+		}
+	}
+
+	return &stagedFile{
+		data:   data,
+		head:   data[:min(len(data), sniffBytes)],
+		size:   int64(len(data)),
+		sha256: contentHash(data),
+	}, nil
+}
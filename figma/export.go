@@ -0,0 +1,258 @@
+package figma
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"figma-mcp-server/types"
+)
+
+// exportJob 一个待渲染的输出文件，skip非空表示该文件被跳过及原因，fail非空表示参数无效及原因
+type exportJob struct {
+	node   types.ImageNode
+	format string
+	scale  float64
+	skip   string
+	fail   string
+}
+
+// exportGroup 同一格式和缩放比例的任务，对应一次/v1/images请求
+type exportGroup struct {
+	format string
+	scale  float64
+	tasks  []*downloadTask
+}
+
+// defaultExportJobs 按显式指定的格式或文件扩展名决定导出格式，
+// 位图(PNG/JPG)使用全局缩放比例或多倍图设置；格式无法识别的节点只让该节点失败
+func defaultExportJobs(nodes []types.ImageNode, options DownloadOptions) []exportJob {
+	var jobs []exportJob
+	for _, node := range nodes {
+		if node.FileName == "" {
+			jobs = append(jobs, exportJob{node: node, skip: "节点没有导出设置也没有文件名"})
+			continue
+		}
+
+		format, err := detectFormat(node.FileName, node.Format)
+		if err != nil {
+			jobs = append(jobs, exportJob{node: node, fail: err.Error()})
+			continue
+		}
+		if path.Ext(node.FileName) == "" {
+			node.FileName += "." + format
+		}
+
+		if format == "svg" || format == "pdf" {
+			jobs = append(jobs, exportJob{node: node, format: format, scale: 1.0})
+			continue
+		}
+
+		// 节点自带的缩放比例(例如list_figma_assets给出的导出设置)优先于全局设置
+		if node.Scale > 0 {
+			jobs = append(jobs, exportJob{node: node, format: format, scale: node.Scale})
+			continue
+		}
+
+		if len(options.PngScales) == 0 {
+			jobs = append(jobs, exportJob{node: node, format: format, scale: options.PngScale})
+			continue
+		}
+		for _, scale := range options.PngScales {
+			job := exportJob{node: node, format: format, scale: scale}
+			job.node.FileName = scaledFileName(node.FileName, scale, options.ScaleLayout)
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// formatExtensions 文件扩展名对应的Figma导出格式
+var formatExtensions = map[string]string{
+	".png":  "png",
+	".jpg":  "jpg",
+	".jpeg": "jpg",
+	".svg":  "svg",
+	".pdf":  "pdf",
+}
+
+// detectFormat 确定导出格式：显式格式优先，但必须和文件扩展名一致
+func detectFormat(fileName, explicit string) (string, error) {
+	extFormat, known := formatExtensions[strings.ToLower(path.Ext(fileName))]
+
+	if explicit != "" {
+		format := strings.ToLower(explicit)
+		if format == "jpeg" {
+			format = "jpg"
+		}
+		switch format {
+		case "png", "jpg", "svg", "pdf":
+		default:
+			return "", fmt.Errorf("不支持的导出格式: %s", explicit)
+		}
+		if path.Ext(fileName) != "" && extFormat != format {
+			return "", fmt.Errorf("文件名 %s 的扩展名与指定格式 %s 不一致", fileName, format)
+		}
+		return format, nil
+	}
+
+	if !known {
+		return "", fmt.Errorf("无法从文件名 %s 识别格式，请使用.png、.jpg、.svg或.pdf扩展名，或指定format", fileName)
+	}
+	return extFormat, nil
+}
+
+// androidDensities Android各密度目录对应的缩放比例
+var androidDensities = map[float64]string{
+	1:   "drawable-mdpi",
+	1.5: "drawable-hdpi",
+	2:   "drawable-xhdpi",
+	3:   "drawable-xxhdpi",
+	4:   "drawable-xxxhdpi",
+}
+
+// scaledFileName 生成多倍图的文件名，1倍图保持原名
+func scaledFileName(fileName string, scale float64, layout string) string {
+	if layout == "android" {
+		return path.Join(androidDensities[scale], fileName)
+	}
+	if scale == 1 {
+		return fileName
+	}
+
+	ext := path.Ext(fileName)
+	return fmt.Sprintf("%s@%gx%s", strings.TrimSuffix(fileName, ext), scale, ext)
+}
+
+// exportSettingJobs 读取节点的exportSettings，每个导出设置生成一个文件，
+// 文件名为基础名加上设置中的后缀；没有导出设置的节点退回默认行为
+func exportSettingJobs(figmaApiKey, fileKey string, nodes []types.ImageNode, options DownloadOptions) ([]exportJob, error) {
+	var nodeIds []string
+	for _, node := range nodes {
+		nodeIds = append(nodeIds, node.NodeId)
+	}
+
+	apiResponse, err := fetchNodes(figmaApiKey, fileKey, nodeIds, options.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []exportJob
+	// 同名节点或后缀和格式相同的导出设置会得到相同的文件名，追加序号避免互相覆盖
+	usedNames := make(map[string]int)
+	for _, node := range nodes {
+		wrapper, exists := apiResponse.Nodes[node.NodeId]
+		if !exists || wrapper == nil || len(wrapper.Document.ExportSettings) == 0 {
+			jobs = append(jobs, defaultExportJobs([]types.ImageNode{node}, options)...)
+			continue
+		}
+
+		document := wrapper.Document
+		base := strings.TrimSuffix(node.FileName, filepath.Ext(node.FileName))
+		if base == "" {
+			base = slugify(document.Name)
+		}
+
+		for _, setting := range document.ExportSettings {
+			format := strings.ToLower(setting.Format)
+			job := exportJob{node: node, format: format, scale: exportScale(setting, document)}
+			job.node.FileName = uniqueFileName(base+setting.Suffix, format, usedNames)
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// fetchNodes 分批请求/nodes(depth=1)并合并结果，批次大小与/v1/images相同
+// 文件中不存在的节点在Nodes中为nil；重试后仍有批次失败时返回错误和已合并的部分结果
+func fetchNodes(figmaApiKey, fileKey string, nodeIds []string, batchSize int) (*types.FigmaAPINodeResponse, error) {
+	merged := &types.FigmaAPINodeResponse{Nodes: make(map[string]*types.FigmaNodeWrapper)}
+	succeeded := false
+
+	failures := requestInBatches(nodeIds, batchSize, func(batch []string) ([]string, error) {
+		params := url.Values{}
+		params.Add("ids", strings.Join(batch, ","))
+		params.Add("depth", "1")
+
+		resp, err := figmaGet(figmaApiKey, fmt.Sprintf("https://api.figma.com/v1/files/%s/nodes?%s", fileKey, params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		var apiResponse types.FigmaAPINodeResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %v", err)
+		}
+
+		if !succeeded {
+			succeeded = true
+			merged.Name = apiResponse.Name
+			merged.LastModified = apiResponse.LastModified
+			merged.Version = apiResponse.Version
+			merged.ThumbnailUrl = apiResponse.ThumbnailUrl
+		}
+
+		for id, wrapper := range apiResponse.Nodes {
+			merged.Nodes[id] = wrapper
+		}
+		// 不存在的节点同样算作已得到结果，不再重试
+		return batch, nil
+	})
+
+	for _, id := range nodeIds {
+		if err := failures[id]; err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// exportScale 将导出约束换算为缩放比例，WIDTH/HEIGHT约束按节点尺寸计算
+func exportScale(setting types.ExportSetting, node types.FigmaNode) float64 {
+	if setting.Format == "SVG" || setting.Format == "PDF" || setting.Constraint.Value <= 0 {
+		return 1.0
+	}
+
+	switch setting.Constraint.Type {
+	case "WIDTH":
+		if box := node.AbsoluteBoundingBox; box != nil && box.Width > 0 {
+			return setting.Constraint.Value / box.Width
+		}
+	case "HEIGHT":
+		if box := node.AbsoluteBoundingBox; box != nil && box.Height > 0 {
+			return setting.Constraint.Value / box.Height
+		}
+	case "SCALE":
+		return setting.Constraint.Value
+	}
+	return 1.0
+}
+
+// groupDownloadTasks 按格式和缩放比例分组需要渲染的任务，保持首次出现的顺序
+// 已有结果(跳过或失败)的任务不再请求地址
+func groupDownloadTasks(tasks []*downloadTask) []exportGroup {
+	var groups []exportGroup
+	index := make(map[string]int)
+
+	for _, task := range tasks {
+		if task.result.Status != "" || task.job.node.ImageRef != "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s@%g", task.job.format, task.job.scale)
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, exportGroup{format: task.job.format, scale: task.job.scale})
+		}
+		groups[i].tasks = append(groups[i].tasks, task)
+	}
+
+	return groups
+}
@@ -0,0 +1,79 @@
+package figma
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sniffFormat 根据内容判断图片填充原图的格式
+func sniffFormat(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "png"
+	case "image/jpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	case "application/pdf":
+		return "pdf"
+	}
+	if bytes.Contains(data[:min(len(data), 512)], []byte("<svg")) {
+		return "svg"
+	}
+	return ""
+}
+
+// imageDimensions 读取位图的像素尺寸或SVG根元素的width/height，无法识别时返回0
+// 位图只解码文件头，不会把整个文件读入内存
+func imageDimensions(r io.Reader, format string) (int, int) {
+	if format == "svg" {
+		return svgDimensions(r)
+	}
+
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+func svgDimensions(r io.Reader) (int, int) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			var width, height int
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "width":
+					width = svgLength(attr.Value)
+				case "height":
+					height = svgLength(attr.Value)
+				}
+			}
+			return width, height
+		}
+	}
+}
+
+// svgLength 解析不带单位或px单位的长度
+func svgLength(value string) int {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil {
+		return 0
+	}
+	return int(v + 0.5)
+}
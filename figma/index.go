@@ -0,0 +1,66 @@
+package figma
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"figma-mcp-server/types"
+
+	"gopkg.in/yaml.v2"
+)
+
+// indexDepth 文件索引只需要页面及其直接子节点
+const indexDepth = 2
+
+// GetFigmaFileIndex 以depth=2获取整个文件，只返回页面和页面的直接子节点及其ID和尺寸
+// 适合在获取具体节点之前快速浏览大文件
+func GetFigmaFileIndex(figmaApiKey, fileKey string) (string, error) {
+	resp, err := figmaGet(figmaApiKey, figmaDataURL(fileKey, nil, indexDepth))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var apiResponse types.FigmaAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	yamlData, err := yaml.Marshal(buildFileIndex(apiResponse))
+	if err != nil {
+		return "", err
+	}
+	return string(yamlData), nil
+}
+
+// buildFileIndex 从文件响应中提取页面和顶层节点，隐藏的节点不列出
+func buildFileIndex(apiResponse types.FigmaAPIResponse) types.FileIndex {
+	index := types.FileIndex{
+		Name:         apiResponse.Name,
+		LastModified: apiResponse.LastModified,
+		Version:      apiResponse.Version,
+		Pages:        []types.PageIndex{},
+	}
+
+	for _, page := range apiResponse.Document.Children {
+		if !isVisible(page) {
+			continue
+		}
+
+		pageIndex := types.PageIndex{ID: page.ID, Name: page.Name, Children: []types.FrameIndex{}}
+		for _, child := range page.Children {
+			if !isVisible(child) {
+				continue
+			}
+			frame := types.FrameIndex{ID: child.ID, Name: child.Name, Type: child.Type}
+			if child.AbsoluteBoundingBox != nil {
+				frame.Width = child.AbsoluteBoundingBox.Width
+				frame.Height = child.AbsoluteBoundingBox.Height
+			}
+			pageIndex.Children = append(pageIndex.Children, frame)
+		}
+		index.Pages = append(index.Pages, pageIndex)
+	}
+
+	return index
+}
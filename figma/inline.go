@@ -0,0 +1,48 @@
+package figma
+
+// 内联返回的大小上限，避免单次工具结果过大
+const (
+	defaultMaxInlineBytes = 1 << 20
+	maxInlineTotalBytes   = 5 << 20
+)
+
+// inlineMimeTypes 可以内联返回的格式，PDF不内联
+var inlineMimeTypes = map[string]string{
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"svg":  "image/svg+xml",
+}
+
+// InlineMimeType 返回格式对应的MIME类型，不支持内联时返回空字符串
+func InlineMimeType(format string) string {
+	return inlineMimeTypes[format]
+}
+
+// inlineResults 按顺序标记可以内联返回的文件，超过单文件或总大小上限的文件不内联
+func inlineResults(tasks []*downloadTask, maxBytes int) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxInlineBytes
+	}
+
+	total := 0
+	for _, task := range tasks {
+		result := &task.result
+		if result.Data == nil {
+			continue
+		}
+
+		switch {
+		case InlineMimeType(result.Format) == "":
+			result.InlineNote = "该格式不支持内联返回"
+		case len(result.Data) > maxBytes:
+			result.InlineNote = "文件超过单个内联大小上限"
+		case total+len(result.Data) > maxInlineTotalBytes:
+			result.InlineNote = "超过本次内联总大小上限"
+		default:
+			result.Inlined = true
+			total += len(result.Data)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package figma
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"figma-mcp-server/types"
+)
+
+// absoluteInsets 根据约束计算绝对定位子节点的top/right/bottom/left
+// 没有约束时按LEFT/TOP处理
+func absoluteInsets(node, parent types.FigmaNode) (top, right, bottom, left string) {
+	if node.AbsoluteBoundingBox == nil || parent.AbsoluteBoundingBox == nil {
+		return
+	}
+
+	box := node.AbsoluteBoundingBox
+	parentBox := parent.AbsoluteBoundingBox
+	x := box.X - parentBox.X
+	y := box.Y - parentBox.Y
+
+	horizontal, vertical := "LEFT", "TOP"
+	if node.Constraints != nil {
+		horizontal, vertical = node.Constraints.Horizontal, node.Constraints.Vertical
+	}
+
+	left, right = axisInsets(horizontal, x, box.Width, parentBox.Width)
+	top, bottom = axisInsets(vertical, y, box.Height, parentBox.Height)
+	return
+}
+
+// axisInsets 计算单个轴向上的起止偏移
+// offset为相对父节点起点的距离，size和parentSize分别为节点和父节点在该轴的尺寸
+func axisInsets(constraint string, offset, size, parentSize float64) (start, end string) {
+	endOffset := parentSize - offset - size
+
+	switch constraint {
+	case "RIGHT", "BOTTOM":
+		return "", formatPx(endOffset)
+	case "LEFT_RIGHT", "TOP_BOTTOM":
+		return formatPx(offset), formatPx(endOffset)
+	case "CENTER":
+		// 以父节点中线为基准，父节点缩放时保持居中偏移
+		return fmt.Sprintf("calc(50%% + %s)", formatPx(offset-parentSize/2)), ""
+	case "SCALE":
+		if parentSize == 0 {
+			return formatPx(offset), formatPx(endOffset)
+		}
+		return formatNumber(offset/parentSize*100) + "%", formatNumber(endOffset/parentSize*100) + "%"
+	default:
+		return formatPx(offset), ""
+	}
+}
+
+// applyConstraintHints 写入约束锚定方式，拉伸和缩放约束同时给出宽高提示
+func applyConstraintHints(layout *types.SimplifiedLayout, node, parent types.FigmaNode) {
+	if node.Constraints == nil {
+		return
+	}
+
+	layout.Constraints = &types.SimplifiedConstraints{
+		Horizontal: strings.ReplaceAll(strings.ToLower(node.Constraints.Horizontal), "_", "-"),
+		Vertical:   strings.ReplaceAll(strings.ToLower(node.Constraints.Vertical), "_", "-"),
+	}
+
+	if node.AbsoluteBoundingBox == nil || parent.AbsoluteBoundingBox == nil {
+		return
+	}
+
+	switch node.Constraints.Horizontal {
+	case "LEFT_RIGHT":
+		layout.Width = "auto"
+	case "SCALE":
+		if parent.AbsoluteBoundingBox.Width > 0 {
+			layout.Width = formatNumber(node.AbsoluteBoundingBox.Width/parent.AbsoluteBoundingBox.Width*100) + "%"
+		}
+	}
+
+	switch node.Constraints.Vertical {
+	case "TOP_BOTTOM":
+		layout.Height = "auto"
+	case "SCALE":
+		if parent.AbsoluteBoundingBox.Height > 0 {
+			layout.Height = formatNumber(node.AbsoluteBoundingBox.Height/parent.AbsoluteBoundingBox.Height*100) + "%"
+		}
+	}
+}
+
+// applyAutoLayoutExtras 处理flex-grow、最小/最大尺寸、换行的交叉轴间距、grid布局和渲染边界
+func applyAutoLayoutExtras(layout *types.SimplifiedLayout, node types.FigmaNode, parent *types.FigmaNode) {
+	if node.LayoutGrow != nil && *node.LayoutGrow > 0 {
+		layout.FlexGrow = *node.LayoutGrow
+	}
+
+	if node.MinWidth != nil {
+		layout.MinWidth = formatPx(*node.MinWidth)
+	}
+	if node.MaxWidth != nil {
+		layout.MaxWidth = formatPx(*node.MaxWidth)
+	}
+	if node.MinHeight != nil {
+		layout.MinHeight = formatPx(*node.MinHeight)
+	}
+	if node.MaxHeight != nil {
+		layout.MaxHeight = formatPx(*node.MaxHeight)
+	}
+
+	// 换行时counterAxisSpacing是行与行之间的间距
+	if node.CounterAxisSpacing != nil {
+		if node.LayoutMode == "VERTICAL" {
+			layout.ColumnGap = formatPx(*node.CounterAxisSpacing)
+		} else {
+			layout.RowGap = formatPx(*node.CounterAxisSpacing)
+		}
+	}
+	if node.CounterAxisAlignContent == "SPACE_BETWEEN" {
+		layout.AlignContent = "space-between"
+	}
+
+	if node.LayoutMode == "GRID" {
+		layout.GridTemplateColumns = gridTemplate(node.GridColumnsSizing, node.GridColumnCount)
+		layout.GridTemplateRows = gridTemplate(node.GridRowsSizing, node.GridRowCount)
+		if node.GridRowGap != nil {
+			layout.RowGap = formatPx(*node.GridRowGap)
+		}
+		if node.GridColumnGap != nil {
+			layout.ColumnGap = formatPx(*node.GridColumnGap)
+		}
+	}
+
+	if parent != nil && parent.LayoutMode == "GRID" {
+		layout.GridColumn = gridPlacement(node.GridColumnAnchorIndex, node.GridColumnSpan)
+		layout.GridRow = gridPlacement(node.GridRowAnchorIndex, node.GridRowSpan)
+		if align := gridSelfAlign(node.GridChildHorizontalAlign); align != "" {
+			layout.JustifySelf = align
+		}
+		if align := gridSelfAlign(node.GridChildVerticalAlign); align != "" {
+			layout.AlignSelf = align
+		}
+	}
+
+	layout.StrokesIncludedInLayout = node.StrokesIncludedInLayout
+
+	// 渲染边界包含阴影、描边等超出节点框的部分，以相对节点框的偏移表示
+	if render, box := node.AbsoluteRenderBounds, node.AbsoluteBoundingBox; render != nil && box != nil && *render != *box {
+		layout.RenderBounds = map[string]interface{}{
+			"x":      roundValue(render.X - box.X),
+			"y":      roundValue(render.Y - box.Y),
+			"width":  roundValue(render.Width),
+			"height": roundValue(render.Height),
+		}
+	}
+}
+
+// gridTemplate 优先使用Figma给出的轨道尺寸，否则按数量等分
+func gridTemplate(sizing string, count int) string {
+	if sizing != "" {
+		return sizing
+	}
+	if count > 0 {
+		return fmt.Sprintf("repeat(%d, minmax(0, 1fr))", count)
+	}
+	return ""
+}
+
+// gridPlacement 将从0开始的锚点索引和跨度转换为grid-column/grid-row
+func gridPlacement(anchor, span *int) string {
+	switch {
+	case anchor != nil && span != nil && *span > 1:
+		return fmt.Sprintf("%d / span %d", *anchor+1, *span)
+	case anchor != nil:
+		return fmt.Sprintf("%d", *anchor+1)
+	case span != nil && *span > 1:
+		return fmt.Sprintf("span %d", *span)
+	}
+	return ""
+}
+
+func gridSelfAlign(align string) string {
+	switch align {
+	case "MIN":
+		return "start"
+	case "CENTER":
+		return "center"
+	case "MAX":
+		return "end"
+	}
+	return ""
+}
+
+// isFreeformFrame 判断是否为未使用auto-layout的框架，其子节点完全依靠约束定位
+func isFreeformFrame(node types.FigmaNode) bool {
+	switch node.Type {
+	case "FRAME", "COMPONENT", "COMPONENT_SET", "INSTANCE":
+		return node.LayoutMode == "" || node.LayoutMode == "NONE"
+	}
+	return false
+}
+
+// roundValue 保留两位小数
+func roundValue(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,179 @@
+package figma
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"figma-mcp-server/types"
+)
+
+// DownloadManifestName 下载清单在localPath中的文件名
+const DownloadManifestName = ".figma-manifest.json"
+
+// fileVersion Figma文件的版本信息
+type fileVersion struct {
+	version      string
+	lastModified string
+}
+
+// fetchNodeInfo 通过/nodes接口分批读取文件当前的version、lastModified以及各节点的名称
+// 部分批次失败时仍返回已读取的信息和错误
+func fetchNodeInfo(figmaApiKey, fileKey string, nodeIds []string, batchSize int) (fileVersion, map[string]string, error) {
+	apiResponse, err := fetchNodes(figmaApiKey, fileKey, nodeIds, batchSize)
+	if apiResponse == nil {
+		return fileVersion{}, nil, err
+	}
+
+	names := make(map[string]string, len(apiResponse.Nodes))
+	for id, wrapper := range apiResponse.Nodes {
+		if wrapper != nil {
+			names[id] = wrapper.Document.Name
+		}
+	}
+
+	return fileVersion{version: apiResponse.Version, lastModified: apiResponse.LastModified}, names, err
+}
+
+// loadManifest 读取localPath中的清单，不存在、损坏或属于其他文件时返回空清单
+func loadManifest(dir, fileKey string) *types.DownloadManifest {
+	manifest := &types.DownloadManifest{FileKey: fileKey, Files: make(map[string]types.ManifestEntry)}
+
+	data, err := os.ReadFile(filepath.Join(dir, DownloadManifestName))
+	if err != nil {
+		return manifest
+	}
+
+	var existing types.DownloadManifest
+	if err := json.Unmarshal(data, &existing); err != nil || existing.FileKey != fileKey || existing.Files == nil {
+		return manifest
+	}
+	return &existing
+}
+
+// saveManifest 原子地写入清单
+func saveManifest(dir string, manifest *types.DownloadManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, DownloadManifestName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// unchangedEntry 判断清单中的文件是否仍然有效：来源和后处理方式相同、版本未变且磁盘上的内容哈希一致
+// 图片填充的imageRef本身就是内容标识，不需要比较版本；重复的文件同样检查自身的内容
+func unchangedEntry(dir, name string, task *downloadTask, entry types.ManifestEntry, version fileVersion) bool {
+	job := task.job
+	if entry.NodeId != job.node.NodeId || entry.ImageRef != job.node.ImageRef ||
+		entry.Format != job.format || entry.Scale != job.scale || entry.Variant != task.svgOptimize.variant() {
+		return false
+	}
+	if job.node.ImageRef == "" && (version.version == "" || entry.Version != version.version) {
+		return false
+	}
+
+	return fileHash(filepath.Join(dir, name)) == entry.SHA256
+}
+
+// manifestKey 查找文件在清单中的键；图片填充的扩展名可能在下载时被更正，
+// 因此请求的文件名没有记录时再按imageRef查找基础名相同的记录
+func manifestKey(manifest *types.DownloadManifest, key, imageRef string) string {
+	if _, ok := manifest.Files[key]; ok || imageRef == "" {
+		return key
+	}
+
+	base := strings.TrimSuffix(key, path.Ext(key))
+	for name, entry := range manifest.Files {
+		if entry.ImageRef == imageRef && strings.TrimSuffix(name, path.Ext(name)) == base {
+			return name
+		}
+	}
+	return key
+}
+
+// fileHash 流式计算磁盘文件的sha256，读取失败时返回空字符串
+func fileHash(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// contentIndex 记录本批次已写入的内容哈希，用于去掉重复的渲染结果
+type contentIndex struct {
+	mu     sync.Mutex
+	hashes map[string]*downloadTask
+}
+
+func newContentIndex() *contentIndex {
+	return &contentIndex{hashes: make(map[string]*downloadTask)}
+}
+
+// claim 登记内容哈希，已被其他任务登记时返回该任务
+func (c *contentIndex) claim(hash string, task *downloadTask) *downloadTask {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if first, ok := c.hashes[hash]; ok {
+		return first
+	}
+	c.hashes[hash] = task
+	return nil
+}
+
+// updateManifest 把本次的结果合并到清单中，没有涉及的文件保持不变
+func updateManifest(manifest *types.DownloadManifest, tasks []*downloadTask, version fileVersion) {
+	manifest.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if version.version != "" {
+		manifest.Version = version.version
+		manifest.LastModified = version.lastModified
+	}
+
+	for _, task := range tasks {
+		result := task.result
+		if result.Status != "downloaded" && result.Status != "unchanged" && result.Status != "duplicate" {
+			continue
+		}
+
+		entry := types.ManifestEntry{
+			NodeId:      task.job.node.NodeId,
+			ImageRef:    task.job.node.ImageRef,
+			Format:      task.job.format,
+			Scale:       task.job.scale,
+			Variant:     task.svgOptimize.variant(),
+			SHA256:      result.SHA256,
+			Bytes:       result.Bytes,
+			DuplicateOf: result.DuplicateOf,
+		}
+		if task.job.node.ImageRef == "" {
+			entry.Version = version.version
+			entry.LastModified = version.lastModified
+		}
+		manifest.Files[result.FileName] = entry
+	}
+}
@@ -0,0 +1,273 @@
+package figma
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 已存在文件的处理策略
+const (
+	OverwriteReplace = "overwrite"
+	OverwriteSkip    = "skip"
+	OverwriteRename  = "rename"
+)
+
+var (
+	downloadRootsMu sync.RWMutex
+	// 允许写入的根目录(已解析符号链接)，为空时使用当前工作目录
+	downloadRoots []string
+)
+
+// SetDownloadRoots 设置下载允许写入的根目录，目录必须已存在
+// 第一个根目录同时作为相对localPath的基准目录
+func SetDownloadRoots(roots []string) error {
+	var resolved []string
+	for _, root := range roots {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		real, err := realPath(root)
+		if err != nil {
+			return fmt.Errorf("下载根目录 %s 无效: %v", root, err)
+		}
+		resolved = append(resolved, real)
+	}
+
+	downloadRootsMu.Lock()
+	downloadRoots = resolved
+	downloadRootsMu.Unlock()
+	return nil
+}
+
+// allowedRoots 返回当前允许的根目录
+func allowedRoots() ([]string, error) {
+	downloadRootsMu.RLock()
+	roots := downloadRoots
+	downloadRootsMu.RUnlock()
+	if len(roots) > 0 {
+		return roots, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	real, err := realPath(wd)
+	if err != nil {
+		return nil, err
+	}
+	return []string{real}, nil
+}
+
+// resolveDownloadDir 将localPath限制在允许的根目录内并创建目录
+// 相对路径基于第一个根目录，绝对路径必须位于某个根目录之下，符号链接按真实路径检查
+func resolveDownloadDir(localPath string) (string, error) {
+	roots, err := allowedRoots()
+	if err != nil {
+		return "", fmt.Errorf("读取下载根目录失败: %v", err)
+	}
+
+	dir := filepath.Clean(localPath)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(roots[0], dir)
+	}
+
+	// 先检查已存在部分的真实路径，避免通过符号链接在根目录外创建目录
+	if real, err := realExistingPath(dir); err != nil {
+		return "", err
+	} else if !withinAny(real, roots) {
+		return "", fmt.Errorf("路径 %s 不在允许的下载目录内", localPath)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	real, err := realPath(dir)
+	if err != nil {
+		return "", err
+	}
+	if !withinAny(real, roots) {
+		return "", fmt.Errorf("路径 %s 不在允许的下载目录内", localPath)
+	}
+	return real, nil
+}
+
+// safeFileName 规范化文件名，拒绝绝对路径和跳出下载目录的相对路径
+// 允许子目录，例如drawable-xxhdpi/name.png
+func safeFileName(fileName string) (string, error) {
+	name := filepath.Clean(filepath.FromSlash(fileName))
+	if name == "." || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("文件名 %q 无效", fileName)
+	}
+	if name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("文件名 %q 超出下载目录", fileName)
+	}
+	return name, nil
+}
+
+// prepareFilePath 在下载目录内确定输出路径并创建子目录
+// 返回的名称在rename策略下可能与原文件名不同；文件已存在且策略为skip时exists为true
+func prepareFilePath(dir, fileName, policy string, reserved map[string]bool) (name string, exists bool, err error) {
+	name, err = safeFileName(fileName)
+	if err != nil {
+		return "", false, err
+	}
+
+	filePath := filepath.Join(dir, name)
+
+	// 先检查已存在部分的真实路径，避免通过符号链接在下载目录外创建目录
+	if real, err := realExistingPath(filepath.Dir(filePath)); err != nil {
+		return "", false, err
+	} else if !within(real, dir) {
+		return "", false, fmt.Errorf("文件名 %q 通过符号链接超出下载目录", fileName)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", false, fmt.Errorf("创建目录失败: %v", err)
+	}
+	parent, err := realPath(filepath.Dir(filePath))
+	if err != nil {
+		return "", false, err
+	}
+	if !within(parent, dir) {
+		return "", false, fmt.Errorf("文件名 %q 通过符号链接超出下载目录", fileName)
+	}
+
+	switch policy {
+	case OverwriteSkip:
+		if reserved[name] || fileExists(filePath) {
+			return name, true, nil
+		}
+	case OverwriteRename:
+		name = freeFileName(dir, name, reserved)
+		filePath = filepath.Join(dir, name)
+	default:
+		// 覆盖策略只覆盖此前已存在的文件；同一批次中已分配的文件名另选名称，
+		// 否则两个任务会并发写入同一路径并且都报告成功
+		if reserved[name] {
+			name = freeFileName(dir, name, reserved)
+			filePath = filepath.Join(dir, name)
+		}
+	}
+
+	// 不跟随已存在的符号链接写入
+	if info, err := os.Lstat(filePath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", false, fmt.Errorf("文件 %q 是符号链接，拒绝写入", fileName)
+	}
+
+	reserved[name] = true
+	return filepath.ToSlash(name), false, nil
+}
+
+// freeFileName 追加序号，直到文件名既没有被本批次分配也不存在于磁盘上
+func freeFileName(dir, name string, reserved map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; reserved[name] || fileExists(filepath.Join(dir, name)); i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return name
+}
+
+// reserveFileName 规范化不写入磁盘的文件名，重名时追加序号
+func reserveFileName(fileName string, reserved map[string]bool) (string, error) {
+	name, err := safeFileName(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; reserved[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	reserved[name] = true
+	return filepath.ToSlash(name), nil
+}
+
+// outputPaths 一次下载中输出文件名的分配状态
+// 图片填充在下载后才能确定扩展名，此时worker会并发地重新分配文件名，需要加锁
+type outputPaths struct {
+	mu       sync.Mutex
+	dir      string
+	policy   string
+	reserved map[string]bool
+}
+
+func newOutputPaths(dir, policy string) *outputPaths {
+	return &outputPaths{dir: dir, policy: policy, reserved: make(map[string]bool)}
+}
+
+// reserve 分配输出文件名：写入磁盘时同prepareFilePath，否则同reserveFileName
+func (p *outputPaths) reserve(fileName string) (name string, exists bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.dir == "" {
+		name, err = reserveFileName(fileName, p.reserved)
+		return name, false, err
+	}
+	return prepareFilePath(p.dir, fileName, p.policy, p.reserved)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// realPath 返回绝对路径并解析符号链接
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// realExistingPath 解析路径中已存在的最长前缀，再拼上尚不存在的部分
+func realExistingPath(path string) (string, error) {
+	var missing []string
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				real = filepath.Join(real, missing[i])
+			}
+			return real, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(path))
+		path = parent
+	}
+}
+
+func withinAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if within(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// within 判断path是否等于root或位于root之下
+func within(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,161 @@
+package figma
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sandboxDirs 返回已解析符号链接的下载目录和位于其外部的目录
+func sandboxDirs(t *testing.T) (dir, outside string) {
+	t.Helper()
+	base, err := realPath(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = filepath.Join(base, "downloads")
+	outside = filepath.Join(base, "outside")
+	for _, d := range []string{dir, outside} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, outside
+}
+
+func TestPrepareFilePathRejectsEscapes(t *testing.T) {
+	dir, outside := sandboxDirs(t)
+
+	tests := []struct {
+		name     string
+		fileName string
+	}{
+		{"parent", "../x.png"},
+		{"nested parent", "a/../../x.png"},
+		{"absolute", filepath.Join(outside, "x.png")},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := prepareFilePath(dir, tt.fileName, OverwriteReplace, map[string]bool{}); err == nil {
+				t.Fatalf("prepareFilePath(%q) 应当返回错误", tt.fileName)
+			}
+		})
+	}
+
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("下载目录外不应创建文件: %v", entries)
+	}
+}
+
+func TestPrepareFilePathSymlinkedDirectory(t *testing.T) {
+	dir, outside := sandboxDirs(t)
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+
+	for _, fileName := range []string{"link/x.png", "link/sub/deep/x.png"} {
+		if _, _, err := prepareFilePath(dir, fileName, OverwriteReplace, map[string]bool{}); err == nil {
+			t.Fatalf("prepareFilePath(%q) 应当拒绝通过符号链接写入", fileName)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "sub")); !os.IsNotExist(err) {
+		t.Fatalf("不应在下载目录外创建子目录, err=%v", err)
+	}
+}
+
+func TestPrepareFilePathSymlinkedFile(t *testing.T) {
+	dir, outside := sandboxDirs(t)
+	target := filepath.Join(outside, "secret.png")
+	if err := os.WriteFile(target, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "x.png")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+
+	for _, policy := range []string{OverwriteReplace, OverwriteSkip} {
+		_, exists, err := prepareFilePath(dir, "x.png", policy, map[string]bool{})
+		if err == nil && !exists {
+			t.Fatalf("策略 %s 不应允许写入符号链接", policy)
+		}
+	}
+
+	// rename会另选一个不是符号链接的文件名
+	name, _, err := prepareFilePath(dir, "x.png", OverwriteRename, map[string]bool{})
+	if err != nil || name != "x-1.png" {
+		t.Fatalf("rename得到 %q, %v，期望 x-1.png", name, err)
+	}
+}
+
+func TestPrepareFilePathSubdirectory(t *testing.T) {
+	dir, _ := sandboxDirs(t)
+
+	name, exists, err := prepareFilePath(dir, "drawable-xxhdpi/icon.png", OverwriteReplace, map[string]bool{})
+	if err != nil || exists {
+		t.Fatalf("prepareFilePath: %q, %v, %v", name, exists, err)
+	}
+	if name != "drawable-xxhdpi/icon.png" {
+		t.Fatalf("得到 %q", name)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "drawable-xxhdpi")); err != nil || !info.IsDir() {
+		t.Fatalf("子目录没有创建: %v", err)
+	}
+}
+
+func TestResolveDownloadDir(t *testing.T) {
+	root, outside := sandboxDirs(t)
+	if err := SetDownloadRoots([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetDownloadRoots(nil) })
+
+	if dir, err := resolveDownloadDir("assets/icons"); err != nil || dir != filepath.Join(root, "assets", "icons") {
+		t.Fatalf("相对路径: %q, %v", dir, err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+	for _, localPath := range []string{"../outside", outside, "link/sub"} {
+		if _, err := resolveDownloadDir(localPath); err == nil {
+			t.Fatalf("resolveDownloadDir(%q) 应当返回错误", localPath)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "sub")); !os.IsNotExist(err) {
+		t.Fatalf("不应在根目录外创建目录, err=%v", err)
+	}
+}
+
+func TestPrepareFilePathReservedNames(t *testing.T) {
+	dir, _ := sandboxDirs(t)
+	if err := os.WriteFile(filepath.Join(dir, "x-1.png"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy string
+		second string
+		exists bool
+	}{
+		{OverwriteReplace, "x-2.png", false},
+		{OverwriteRename, "x-2.png", false},
+		{OverwriteSkip, "x.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			reserved := map[string]bool{}
+			first, _, err := prepareFilePath(dir, "x.png", tt.policy, reserved)
+			if err != nil || first != "x.png" {
+				t.Fatalf("第一次得到 %q, %v", first, err)
+			}
+
+			// 同一批次中重名的文件不能写入同一路径
+			second, exists, err := prepareFilePath(dir, "x.png", tt.policy, reserved)
+			if err != nil || second != tt.second || exists != tt.exists {
+				t.Fatalf("第二次得到 %q, %v, %v，期望 %q, %v", second, exists, err, tt.second, tt.exists)
+			}
+		})
+	}
+}
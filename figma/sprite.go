@@ -0,0 +1,472 @@
+package figma
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"figma-mcp-server/types"
+)
+
+const defaultComponentDir = "icons"
+
+// componentExtensions 各框架组件文件的扩展名
+var componentExtensions = map[string]string{
+	"react":  ".tsx",
+	"vue":    ".vue",
+	"svelte": ".svelte",
+}
+
+// iconSource 一个用于生成雪碧图和组件的SVG图标
+type iconSource struct {
+	nodeId    string
+	name      string
+	component string
+	symbolId  string
+	root      *svgNode
+}
+
+// 根元素上不下移到symbol和组件内容的属性
+var svgRootOnlyAttrs = map[string]bool{
+	"width":   true,
+	"height":  true,
+	"viewBox": true,
+	"x":       true,
+	"y":       true,
+	"version": true,
+	"id":      true,
+}
+
+// identifierPattern 可以出现在JS/TS标识符中的字符，包括中文等Unicode字母
+var identifierPattern = regexp.MustCompile(`[\p{L}\p{Nl}\p{Mn}\p{Mc}\p{Nd}]+`)
+
+// collectIcons 从下载结果中读取SVG，生成组件名和symbol ID
+// 组件名使用节点名，没有节点名时使用文件名；雪碧图和组件只读取图标的节点树
+func collectIcons(tasks []*downloadTask, nodeNames map[string]string) ([]iconSource, error) {
+	var icons []iconSource
+	usedComponents := map[string]bool{}
+	usedSymbols := map[string]bool{}
+
+	for _, task := range tasks {
+		result := task.result
+		if task.job.format != "svg" || (result.Status != "downloaded" && result.Status != "unchanged" && result.Status != "duplicate") {
+			continue
+		}
+
+		data := result.Data
+		if data == nil {
+			var err error
+			if data, err = os.ReadFile(result.Path); err != nil {
+				return nil, fmt.Errorf("读取 %s 失败: %v", result.FileName, err)
+			}
+		}
+		root, err := parseSVG(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", result.FileName, err)
+		}
+
+		name := nodeNames[result.NodeId]
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(result.FileName), path.Ext(result.FileName))
+		}
+
+		icon := iconSource{
+			nodeId:    result.NodeId,
+			name:      name,
+			component: uniqueName(componentName(name), usedComponents),
+			symbolId:  uniqueName(symbolId(name), usedSymbols),
+			root:      root,
+		}
+		// 内部ID加上图标前缀，合并到雪碧图或同一页面时不会冲突
+		prefixSVGIds(icon.root, icon.symbolId+"-")
+		icons = append(icons, icon)
+	}
+
+	return icons, nil
+}
+
+// componentName 将节点名转换为PascalCase组件名，保留中文等Unicode字母；不以字母开头时加上Icon前缀
+func componentName(name string) string {
+	var sb strings.Builder
+	for _, word := range identifierPattern.FindAllString(name, -1) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	component := sb.String()
+	if first, _ := utf8.DecodeRuneInString(component); component == "" || !(unicode.IsLetter(first) || unicode.Is(unicode.Nl, first)) {
+		component = "Icon" + component
+	}
+	return component
+}
+
+// symbolId 由节点名生成symbol ID，ID不能以数字开头
+func symbolId(name string) string {
+	id := slugify(name)
+	if unicode.IsDigit([]rune(id)[0]) {
+		id = "icon-" + id
+	}
+	return id
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// svgViewBox 返回根元素的viewBox，没有时由width和height生成
+func svgViewBox(root *svgNode) string {
+	if viewBox := svgAttr(root, "viewBox"); viewBox != "" {
+		return viewBox
+	}
+	width, height := svgLength(svgAttr(root, "width")), svgLength(svgAttr(root, "height"))
+	if width == 0 || height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("0 0 %d %d", width, height)
+}
+
+// inheritedRootAttrs 根元素上需要保留的表现属性，例如fill="none"
+func inheritedRootAttrs(root *svgNode) []xml.Attr {
+	var attrs []xml.Attr
+	for _, attr := range root.attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && (attr.Name.Local == "xmlns" || svgRootOnlyAttrs[attr.Name.Local])) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// prefixSVGIds 给图标内部的ID和引用加前缀，避免合并到同一个文档后冲突
+func prefixSVGIds(node *svgNode, prefix string) {
+	for i, attr := range node.attrs {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "id":
+			node.attrs[i].Value = prefix + attr.Value
+		case attr.Name.Local == "href" && strings.HasPrefix(attr.Value, "#"):
+			node.attrs[i].Value = "#" + prefix + attr.Value[1:]
+		default:
+			node.attrs[i].Value = svgReferencePattern.ReplaceAllString(attr.Value, "url(#"+prefix+"$1)")
+		}
+	}
+	for _, child := range node.children {
+		if child.isText && node.name.Local == "style" {
+			child.text = svgReferencePattern.ReplaceAllString(child.text, "url(#"+prefix+"$1)")
+		}
+		if !child.isText {
+			prefixSVGIds(child, prefix)
+		}
+	}
+}
+
+// buildSprite 把图标合并为<symbol>雪碧图，使用时写<use href="sprite.svg#symbolId"/>
+func buildSprite(icons []iconSource) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" style="display:none">`)
+	buf.WriteString("\n")
+
+	for _, icon := range icons {
+		symbol := &svgNode{
+			name:     xml.Name{Local: "symbol"},
+			attrs:    []xml.Attr{{Name: xml.Name{Local: "id"}, Value: icon.symbolId}},
+			children: icon.root.children,
+		}
+		if viewBox := svgViewBox(icon.root); viewBox != "" {
+			symbol.attrs = append(symbol.attrs, xml.Attr{Name: xml.Name{Local: "viewBox"}, Value: viewBox})
+		}
+		symbol.attrs = append(symbol.attrs, inheritedRootAttrs(icon.root)...)
+
+		buf.WriteString("  ")
+		writeSVGNode(&buf, symbol)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// buildComponents 为每个图标生成框架组件
+func buildComponents(icons []iconSource, framework, dir string) []types.GeneratedFile {
+	ext := componentExtensions[framework]
+
+	var files []types.GeneratedFile
+	for _, icon := range icons {
+		var content string
+		switch framework {
+		case "react":
+			content = reactComponent(icon)
+		case "vue":
+			content = vueComponent(icon)
+		case "svelte":
+			content = svelteComponent(icon)
+		}
+
+		files = append(files, types.GeneratedFile{
+			Kind:      "component",
+			FileName:  path.Join(dir, icon.component+ext),
+			NodeId:    icon.nodeId,
+			Component: icon.component,
+			Data:      []byte(content),
+		})
+	}
+
+	return files
+}
+
+// buildComponentIndex 生成导出所有组件的index.ts，导入路径使用组件最终的文件名(rename策略下可能带序号)
+func buildComponentIndex(components []types.GeneratedFile, framework, dir string) types.GeneratedFile {
+	var index strings.Builder
+	for _, file := range components {
+		base := path.Base(file.FileName)
+		if framework == "react" {
+			fmt.Fprintf(&index, "export { %s } from \"./%s\";\n", file.Component, strings.TrimSuffix(base, path.Ext(base)))
+		} else {
+			fmt.Fprintf(&index, "export { default as %s } from \"./%s\";\n", file.Component, base)
+		}
+	}
+
+	return types.GeneratedFile{
+		Kind:     "index",
+		FileName: path.Join(dir, "index.ts"),
+		Data:     []byte(index.String()),
+	}
+}
+
+// componentSVG 组件中的svg根元素：保留viewBox、尺寸和表现属性
+func componentSVG(icon iconSource) *svgNode {
+	root := &svgNode{name: xml.Name{Local: "svg"}, children: icon.root.children}
+	root.attrs = append(root.attrs, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: "http://www.w3.org/2000/svg"})
+	for _, name := range []string{"width", "height"} {
+		if value := svgAttr(icon.root, name); value != "" {
+			root.attrs = append(root.attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+		}
+	}
+	if viewBox := svgViewBox(icon.root); viewBox != "" {
+		root.attrs = append(root.attrs, xml.Attr{Name: xml.Name{Local: "viewBox"}, Value: viewBox})
+	}
+	root.attrs = append(root.attrs, inheritedRootAttrs(icon.root)...)
+	return root
+}
+
+func reactComponent(icon iconSource) string {
+	var markup bytes.Buffer
+	writeJSXNode(&markup, componentSVG(icon), "{...props}")
+
+	return fmt.Sprintf(`import * as React from "react";
+
+// %s
+export function %s(props: React.SVGProps<SVGSVGElement>) {
+  return (
+    %s
+  );
+}
+
+export default %s;
+`, commentText(icon.name), icon.component, markup.String(), icon.component)
+}
+
+func vueComponent(icon iconSource) string {
+	var markup bytes.Buffer
+	writeSVGNode(&markup, componentSVG(icon))
+
+	// 未声明的属性(class、style、事件等)会自动透传到根元素svg上
+	return fmt.Sprintf(`<!-- %s -->
+<template>
+  %s
+</template>
+
+<script setup lang="ts">
+defineOptions({ name: %q });
+</script>
+`, commentText(icon.name), markup.String(), icon.component)
+}
+
+func svelteComponent(icon iconSource) string {
+	var markup bytes.Buffer
+	writeSVGElement(&markup, componentSVG(icon), "{...$$restProps}")
+
+	return fmt.Sprintf(`<!-- %s -->
+<script lang="ts">
+  import type { SVGAttributes } from "svelte/elements";
+  type $$Props = SVGAttributes<SVGSVGElement>;
+</script>
+
+%s
+`, commentText(icon.name), markup.String())
+}
+
+// writeJSXNode 以JSX语法输出节点：属性名转为React的驼峰写法，style转为对象
+func writeJSXNode(buf *bytes.Buffer, node *svgNode, extra string) {
+	if node.isText {
+		// 先做XML转义再替换花括号，否则实体中的&会被再次转义；JSX文本中的花括号是表达式
+		var text bytes.Buffer
+		xml.EscapeText(&text, []byte(node.text))
+		buf.WriteString(strings.NewReplacer("{", "&#123;", "}", "&#125;").Replace(text.String()))
+		return
+	}
+
+	name := rawName(node.name)
+	buf.WriteString("<" + name)
+	for _, attr := range node.attrs {
+		if attr.Name.Space == "xmlns" {
+			continue
+		}
+		if attr.Name.Space == "" && attr.Name.Local == "style" {
+			buf.WriteString(" style={" + jsxStyle(attr.Value) + "}")
+			continue
+		}
+		buf.WriteString(" " + jsxAttrName(attr.Name) + `="`)
+		xml.EscapeText(buf, []byte(attr.Value))
+		buf.WriteString(`"`)
+	}
+	if extra != "" {
+		buf.WriteString(" " + extra)
+	}
+
+	if len(node.children) == 0 {
+		buf.WriteString(" />")
+		return
+	}
+
+	buf.WriteString(">")
+	for _, child := range node.children {
+		writeJSXNode(buf, child, "")
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+// jsxAttrName 将SVG属性名转换为React属性名
+func jsxAttrName(name xml.Name) string {
+	if name.Space != "" {
+		// xlink:href、xml:space等写作xlinkHref、xmlSpace
+		return name.Space + upperFirst(name.Local)
+	}
+	switch {
+	case name.Local == "class":
+		return "className"
+	case strings.HasPrefix(name.Local, "data-"), strings.HasPrefix(name.Local, "aria-"):
+		return name.Local
+	}
+	return camelCase(name.Local)
+}
+
+// jsxStyle 将内联样式转换为React的样式对象
+func jsxStyle(style string) string {
+	var declarations []string
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok || strings.TrimSpace(property) == "" {
+			continue
+		}
+		declarations = append(declarations, fmt.Sprintf("%s: %q", camelCase(strings.TrimSpace(property)), strings.TrimSpace(value)))
+	}
+	sort.Strings(declarations)
+	return "{ " + strings.Join(declarations, ", ") + " }"
+}
+
+// commentText 把节点名放进单行注释，去掉换行和HTML注释结束符
+func commentText(name string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ", "--", "- -", "*/", "* /").Replace(name)
+}
+
+func camelCase(name string) string {
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = upperFirst(parts[i])
+	}
+	return strings.Join(parts, "")
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// generateIconFiles 生成雪碧图、组件和索引文件；设置了下载目录时按覆盖策略写入磁盘
+// paths沿用下载步骤的文件名分配，生成的文件不会与本次下载的图片重名
+func generateIconFiles(tasks []*downloadTask, nodeNames map[string]string, options DownloadOptions, paths *outputPaths) ([]types.GeneratedFile, error) {
+	icons, err := collectIcons(tasks, nodeNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(icons) == 0 {
+		return nil, nil
+	}
+
+	var files []types.GeneratedFile
+	if options.Sprite != "" {
+		sprite := types.GeneratedFile{
+			Kind:     "sprite",
+			FileName: options.Sprite,
+			Data:     buildSprite(icons),
+		}
+		if err := placeGeneratedFile(&sprite, paths); err != nil {
+			return nil, err
+		}
+		files = append(files, sprite)
+	}
+
+	if options.Components != "" {
+		componentDir := options.ComponentDir
+		if componentDir == "" {
+			componentDir = defaultComponentDir
+		}
+		componentDir = filepath.ToSlash(componentDir)
+
+		// 先确定组件的文件名，索引才能引用重命名后的文件
+		components := buildComponents(icons, options.Components, componentDir)
+		for i := range components {
+			if err := placeGeneratedFile(&components[i], paths); err != nil {
+				return nil, err
+			}
+		}
+		index := buildComponentIndex(components, options.Components, componentDir)
+		if err := placeGeneratedFile(&index, paths); err != nil {
+			return nil, err
+		}
+		files = append(files, components...)
+		files = append(files, index)
+	}
+	return files, nil
+}
+
+// placeGeneratedFile 确定生成文件的名称并写入磁盘；没有下载目录(只打包)时只分配文件名
+// 文件已存在且策略为skip时不写入，状态记为skipped
+func placeGeneratedFile(file *types.GeneratedFile, paths *outputPaths) error {
+	file.Bytes = int64(len(file.Data))
+	name, exists, err := paths.reserve(file.FileName)
+	if err != nil {
+		return err
+	}
+	file.FileName = name
+	if paths.dir == "" {
+		return nil
+	}
+
+	file.Path = filepath.Join(paths.dir, name)
+	if exists {
+		file.Status = "skipped"
+		return nil
+	}
+	if err := os.WriteFile(file.Path, file.Data, 0644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	file.Status = "written"
+	return nil
+}
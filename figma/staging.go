@@ -0,0 +1,101 @@
+package figma
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// sniffBytes 识别格式需要的开头字节数
+const sniffBytes = 512
+
+var errFileTooLarge = fmt.Errorf("文件超过 %dMB 的大小上限", maxDownloadBytes>>20)
+
+// stagedFile 已下载但尚未放到最终位置的文件
+// 内容在内存中时data不为空，否则位于同一目录的临时文件tmpPath中
+type stagedFile struct {
+	data    []byte
+	tmpPath string
+	head    []byte
+	size    int64
+	sha256  string
+}
+
+// stageToDisk 把下载内容写入dir中的临时文件，同时计算哈希并保留开头的字节
+func stageToDisk(body io.Reader, dir string) (*stagedFile, error) {
+	tmp, err := os.CreateTemp(dir, ".figma-download-*")
+	if err != nil {
+		return nil, err
+	}
+	file := &stagedFile{tmpPath: tmp.Name()}
+
+	hash := sha256.New()
+	head := &headBuffer{}
+	n, err := io.Copy(io.MultiWriter(tmp, hash, head), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxDownloadBytes {
+		err = errFileTooLarge
+	}
+	if err != nil {
+		file.discard()
+		return nil, err
+	}
+
+	file.head = head.data
+	file.size = n
+	file.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// commit 把文件放到最终路径，临时文件通过重命名替换目标
+func (f *stagedFile) commit(path string) error {
+	if f.tmpPath == "" {
+		return os.WriteFile(path, f.data, 0644)
+	}
+	if err := os.Chmod(f.tmpPath, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(f.tmpPath, path); err != nil {
+		return err
+	}
+	f.tmpPath = ""
+	return nil
+}
+
+// discard 删除没有提交的临时文件
+func (f *stagedFile) discard() {
+	if f.tmpPath != "" {
+		os.Remove(f.tmpPath)
+		f.tmpPath = ""
+	}
+}
+
+// dimensions 读取尺寸；内容不在内存中时读取path处内容相同的文件
+func (f *stagedFile) dimensions(path, format string) (int, int) {
+	if f.tmpPath == "" && f.data != nil {
+		return imageDimensions(bytes.NewReader(f.data), format)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+	return imageDimensions(file, format)
+}
+
+// headBuffer 只保留写入内容开头的sniffBytes个字节
+type headBuffer struct {
+	data []byte
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if remaining := sniffBytes - len(h.data); remaining > 0 {
+		h.data = append(h.data, p[:min(len(p), remaining)]...)
+	}
+	return len(p), nil
+}
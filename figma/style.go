@@ -0,0 +1,295 @@
+package figma
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"figma-mcp-server/types"
+)
+
+// simplifyTextStyle 将Figma的TypeStyle转换为CSS语义的文本样式
+func simplifyTextStyle(style map[string]interface{}) types.SimplifiedTextStyle {
+	textStyle := types.SimplifiedTextStyle{}
+
+	textStyle.FontFamily, _ = style["fontFamily"].(string)
+
+	if weight, ok := floatValue(style, "fontWeight"); ok {
+		textStyle.FontWeight = weight
+	}
+
+	if italic, ok := style["italic"].(bool); ok && italic {
+		textStyle.FontStyle = "italic"
+	}
+
+	fontSize, hasFontSize := floatValue(style, "fontSize")
+	if hasFontSize {
+		textStyle.FontSize = formatPx(fontSize)
+	}
+
+	textStyle.LineHeight = cssLineHeight(style)
+
+	if spacing, ok := floatValue(style, "letterSpacing"); ok && spacing != 0 {
+		textStyle.LetterSpacing = formatPx(spacing)
+	}
+
+	switch style["textCase"] {
+	case "UPPER":
+		textStyle.TextCase = "uppercase"
+	case "LOWER":
+		textStyle.TextCase = "lowercase"
+	case "TITLE":
+		textStyle.TextCase = "capitalize"
+	case "SMALL_CAPS":
+		// 小型大写字母不是text-transform的取值，对应CSS的font-variant
+		textStyle.FontVariant = "small-caps"
+	case "SMALL_CAPS_FORCED":
+		textStyle.FontVariant = "all-small-caps"
+	}
+
+	switch style["textDecoration"] {
+	case "UNDERLINE":
+		textStyle.TextDecoration = "underline"
+	case "STRIKETHROUGH":
+		textStyle.TextDecoration = "line-through"
+	}
+
+	switch style["textAlignHorizontal"] {
+	case "LEFT":
+		textStyle.TextAlignHorizontal = "left"
+	case "CENTER":
+		textStyle.TextAlignHorizontal = "center"
+	case "RIGHT":
+		textStyle.TextAlignHorizontal = "right"
+	case "JUSTIFIED":
+		textStyle.TextAlignHorizontal = "justify"
+	}
+
+	switch style["textAlignVertical"] {
+	case "TOP":
+		textStyle.TextAlignVertical = "top"
+	case "CENTER":
+		textStyle.TextAlignVertical = "middle"
+	case "BOTTOM":
+		textStyle.TextAlignVertical = "bottom"
+	}
+
+	return textStyle
+}
+
+// cssLineHeight 根据lineHeightUnit选择像素或百分比行高
+func cssLineHeight(style map[string]interface{}) string {
+	switch style["lineHeightUnit"] {
+	case "FONT_SIZE_%":
+		if percent, ok := floatValue(style, "lineHeightPercentFontSize"); ok {
+			return formatNumber(percent) + "%"
+		}
+	case "INTRINSIC_%":
+		// INTRINSIC_%是相对字体自身行高的比例，100%即CSS的normal
+		percent, ok := floatValue(style, "lineHeightPercent")
+		if !ok || percent == 100 {
+			return "normal"
+		}
+	}
+
+	if px, ok := floatValue(style, "lineHeightPx"); ok {
+		return formatPx(px)
+	}
+	return ""
+}
+
+// simplifyEffects 将阴影和模糊效果转换为box-shadow、filter和backdrop-filter
+// 文本节点的投影使用text-shadow，不可见的效果会被跳过
+func simplifyEffects(figmaNode types.FigmaNode) types.SimplifiedEffects {
+	var shadows, textShadows, filters, backdropFilters []string
+
+	for _, e := range figmaNode.Effects {
+		effect, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if visible, ok := effect["visible"].(bool); ok && !visible {
+			continue
+		}
+
+		radius, _ := floatValue(effect, "radius")
+
+		switch effect["type"] {
+		case "DROP_SHADOW", "INNER_SHADOW":
+			x, y := 0.0, 0.0
+			if offset, ok := effect["offset"].(map[string]interface{}); ok {
+				x, _ = floatValue(offset, "x")
+				y, _ = floatValue(offset, "y")
+			}
+			spread, _ := floatValue(effect, "spread")
+			color, _ := effect["color"].(map[string]interface{})
+
+			if figmaNode.Type == "TEXT" && effect["type"] == "DROP_SHADOW" {
+				// text-shadow不支持spread和inset
+				textShadows = append(textShadows, fmt.Sprintf("%s %s %s %s",
+					formatPx(x), formatPx(y), formatPx(radius), cssColor(color, 1)))
+				continue
+			}
+
+			shadow := fmt.Sprintf("%s %s %s %s %s",
+				formatPx(x), formatPx(y), formatPx(radius), formatPx(spread), cssColor(color, 1))
+			if effect["type"] == "INNER_SHADOW" {
+				shadow = "inset " + shadow
+			}
+			shadows = append(shadows, shadow)
+		case "LAYER_BLUR":
+			// Figma的模糊半径约为CSS blur()标准差的两倍
+			filters = append(filters, fmt.Sprintf("blur(%s)", formatPx(radius/2)))
+		case "BACKGROUND_BLUR":
+			backdropFilters = append(backdropFilters, fmt.Sprintf("blur(%s)", formatPx(radius/2)))
+		}
+	}
+
+	return types.SimplifiedEffects{
+		BoxShadow:      strings.Join(shadows, ", "),
+		TextShadow:     strings.Join(textShadows, ", "),
+		Filter:         strings.Join(filters, " "),
+		BackdropFilter: strings.Join(backdropFilters, " "),
+	}
+}
+
+// simplifyStrokes 解析描边颜色、逐边宽度、虚线和对齐方式，并给出CSS等价写法
+// 没有可见描边颜色时返回nil
+func simplifyStrokes(figmaNode types.FigmaNode) *types.SimplifiedStroke {
+	stroke := &types.SimplifiedStroke{}
+
+	var cssPaint string
+	for _, p := range figmaNode.Strokes {
+		paint, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if visible, ok := paint["visible"].(bool); ok && !visible {
+			continue
+		}
+
+		paintType, _ := paint["type"].(string)
+		if paintType != "SOLID" {
+			stroke.Colors = append(stroke.Colors, paintType)
+			continue
+		}
+
+		opacity, ok := floatValue(paint, "opacity")
+		if !ok {
+			opacity = 1
+		}
+		color, _ := paint["color"].(map[string]interface{})
+		stroke.Colors = append(stroke.Colors, cssColor(color, opacity))
+		if cssPaint == "" {
+			cssPaint = stroke.Colors[len(stroke.Colors)-1]
+		}
+	}
+
+	if len(stroke.Colors) == 0 {
+		return nil
+	}
+
+	// 宽度：逐边宽度相同时折叠为单值
+	weight := figmaNode.StrokeWeight
+	uniform := true
+	if w := figmaNode.IndividualStrokeWeights; w != nil {
+		if w.Top == w.Right && w.Right == w.Bottom && w.Bottom == w.Left {
+			weight = w.Top
+		} else {
+			uniform = false
+			stroke.Weight = fmt.Sprintf("%s %s %s %s",
+				formatPx(w.Top), formatPx(w.Right), formatPx(w.Bottom), formatPx(w.Left))
+		}
+	}
+	if uniform {
+		stroke.Weight = formatPx(weight)
+	}
+
+	stroke.Align = strings.ToLower(figmaNode.StrokeAlign)
+	stroke.Style = strokeStyle(figmaNode.StrokeDashes, weight, figmaNode.StrokeCap)
+	stroke.Dashes = figmaNode.StrokeDashes
+	if figmaNode.StrokeCap != "" && figmaNode.StrokeCap != "NONE" {
+		stroke.Cap = strings.ToLower(figmaNode.StrokeCap)
+	}
+	stroke.Join = strings.ToLower(figmaNode.StrokeJoin)
+
+	if cssPaint == "" {
+		return stroke
+	}
+
+	switch {
+	case !uniform:
+		stroke.CSS.BorderWidth = stroke.Weight
+		stroke.CSS.BorderStyle = stroke.Style
+		stroke.CSS.BorderColor = cssPaint
+		stroke.CSS.BoxSizing = "border-box"
+	case stroke.Align == "center":
+		// outline向内偏移半个描边宽度，正好跨在边缘上
+		stroke.CSS.Outline = fmt.Sprintf("%s %s %s", stroke.Weight, stroke.Style, cssPaint)
+		stroke.CSS.OutlineOffset = formatPx(-weight / 2)
+	case stroke.Align == "outside":
+		stroke.CSS.Outline = fmt.Sprintf("%s %s %s", stroke.Weight, stroke.Style, cssPaint)
+	default:
+		stroke.CSS.Border = fmt.Sprintf("%s %s %s", stroke.Weight, stroke.Style, cssPaint)
+		stroke.CSS.BoxSizing = "border-box"
+	}
+
+	return stroke
+}
+
+// strokeStyle 根据虚线模式判断solid、dashed或dotted
+// 线段长度不超过描边宽度(或为0的圆头)时视为点线
+func strokeStyle(dashes []float64, weight float64, strokeCap string) string {
+	if len(dashes) == 0 {
+		return "solid"
+	}
+	if (dashes[0] == 0 && strokeCap == "ROUND") || (dashes[0] > 0 && dashes[0] <= weight) {
+		return "dotted"
+	}
+	return "dashed"
+}
+
+// cssColor 将Figma的RGBA颜色(0-1)转换为CSS颜色，不透明时使用十六进制
+func cssColor(color map[string]interface{}, opacity float64) string {
+	r, _ := floatValue(color, "r")
+	g, _ := floatValue(color, "g")
+	b, _ := floatValue(color, "b")
+	a, ok := floatValue(color, "a")
+	if !ok {
+		a = 1
+	}
+	a *= opacity
+
+	r8 := int(math.Round(r * 255))
+	g8 := int(math.Round(g * 255))
+	b8 := int(math.Round(b * 255))
+	if a >= 1 {
+		return fmt.Sprintf("#%02X%02X%02X", r8, g8, b8)
+	}
+	return fmt.Sprintf("rgba(%d, %d, %d, %s)", r8, g8, b8, formatNumber(a))
+}
+
+// floatValue 从原始JSON对象中读取数值
+func floatValue(m map[string]interface{}, key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// formatNumber 保留两位小数并去掉多余的0
+func formatNumber(v float64) string {
+	rounded := roundValue(v)
+	if rounded == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func formatPx(v float64) string {
+	return formatNumber(v) + "px"
+}
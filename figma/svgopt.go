@@ -0,0 +1,479 @@
+package figma
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const defaultSVGPrecision = 3
+
+// svgOptimizeOptions SVG后处理选项，来自svgOptions中的optimize、precision和currentColor
+type svgOptimizeOptions struct {
+	precision    int
+	currentColor bool
+	keepIds      bool
+}
+
+// parseSVGOptimizeOptions 读取SVG后处理选项，没有开启optimize时返回nil
+func parseSVGOptimizeOptions(svgOptions map[string]interface{}) *svgOptimizeOptions {
+	if optimize, ok := svgOptions["optimize"].(bool); !ok || !optimize {
+		return nil
+	}
+
+	opts := &svgOptimizeOptions{precision: defaultSVGPrecision}
+	if precision, ok := svgOptions["precision"].(float64); ok && precision >= 0 {
+		opts.precision = int(precision)
+	}
+	opts.currentColor, _ = svgOptions["currentColor"].(bool)
+	// 要求Figma输出图层ID时保留ID
+	opts.keepIds, _ = svgOptions["includeId"].(bool)
+	return opts
+}
+
+// variant 描述后处理方式，写入清单用于判断文件是否需要重新生成
+func (o *svgOptimizeOptions) variant() string {
+	if o == nil {
+		return ""
+	}
+	v := fmt.Sprintf("svgo:p%d", o.precision)
+	if o.currentColor {
+		v += ",currentColor"
+	}
+	return v
+}
+
+// svgNode 解析后的SVG节点，name.Space保存原始前缀；isText为true时表示文本
+type svgNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*svgNode
+	text     string
+	isText   bool
+}
+
+// 编辑器写入的命名空间前缀，对应的元素和属性会被删除
+var editorPrefixes = map[string]bool{
+	"sodipodi": true,
+	"inkscape": true,
+	"sketch":   true,
+	"serif":    true,
+}
+
+// 保留空白文本的元素
+var textElements = map[string]bool{
+	"text":     true,
+	"tspan":    true,
+	"textPath": true,
+	"style":    true,
+}
+
+// 需要舍入数字的几何属性
+var numericAttrs = map[string]bool{
+	"d": true, "points": true, "transform": true, "gradientTransform": true, "patternTransform": true,
+	"x": true, "y": true, "x1": true, "y1": true, "x2": true, "y2": true,
+	"cx": true, "cy": true, "r": true, "rx": true, "ry": true, "fx": true, "fy": true,
+	"width": true, "height": true, "stroke-width": true, "stroke-dasharray": true, "stroke-dashoffset": true,
+}
+
+// 值为1时不起作用的不透明度属性
+var unitOpacityAttrs = map[string]bool{
+	"opacity":        true,
+	"fill-opacity":   true,
+	"stroke-opacity": true,
+	"stop-opacity":   true,
+}
+
+// 不能从组移动到子元素上的属性，它们依赖组自身的坐标系或合成方式
+var groupOnlyAttrs = map[string]bool{
+	"id":        true,
+	"clip-path": true,
+	"mask":      true,
+	"filter":    true,
+	"style":     true,
+}
+
+var (
+	svgNumberPattern    = regexp.MustCompile(`-?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+	svgReferencePattern = regexp.MustCompile(`url\(\s*['"]?#([^'")\s]+)['"]?\s*\)`)
+)
+
+// optimizeSVG 清理Figma导出的SVG：删除注释、元数据和编辑器属性，删除未被引用的ID，
+// 合并多余的分组，舍入数字，并可以把唯一的颜色替换为currentColor
+func optimizeSVG(data []byte, opts *svgOptimizeOptions) ([]byte, error) {
+	root, err := parseSVG(data)
+	if err != nil {
+		return nil, err
+	}
+
+	references := map[string]bool{}
+	collectReferences(root, references)
+
+	cleanSVGNode(root, opts, references)
+	collapseGroups(root)
+
+	if opts.currentColor {
+		colors := map[string]bool{}
+		collectPaintColors(root, colors)
+		if len(colors) == 1 {
+			for color := range colors {
+				replacePaintColor(root, color)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writeSVGNode(&buf, root)
+	return buf.Bytes(), nil
+}
+
+// parseSVG 使用RawToken解析，保留原始的命名空间前缀
+func parseSVG(data []byte) (*svgNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *svgNode
+	var stack []*svgNode
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析SVG失败: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &svgNode{name: t.Name, attrs: append([]xml.Attr(nil), t.Attr...)}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("解析SVG失败: 存在多个根元素")
+				}
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("解析SVG失败: 标签不匹配")
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			parent := stack[len(stack)-1]
+			text := string(t)
+			if strings.TrimSpace(text) == "" && !textElements[parent.name.Local] {
+				continue
+			}
+			parent.children = append(parent.children, &svgNode{text: text, isText: true})
+		}
+		// 注释、处理指令和DOCTYPE直接丢弃
+	}
+
+	if root == nil || root.name.Local != "svg" {
+		return nil, fmt.Errorf("解析SVG失败: 根元素不是svg")
+	}
+	return root, nil
+}
+
+// collectReferences 收集url(#id)和href="#id"引用的ID
+func collectReferences(node *svgNode, references map[string]bool) {
+	for _, attr := range node.attrs {
+		if attr.Name.Local == "href" && strings.HasPrefix(attr.Value, "#") {
+			references[attr.Value[1:]] = true
+		}
+		for _, match := range svgReferencePattern.FindAllStringSubmatch(attr.Value, -1) {
+			references[match[1]] = true
+		}
+	}
+	for _, child := range node.children {
+		if child.isText && node.name.Local == "style" {
+			for _, match := range svgReferencePattern.FindAllStringSubmatch(child.text, -1) {
+				references[match[1]] = true
+			}
+		}
+		collectReferences(child, references)
+	}
+}
+
+// cleanSVGNode 删除元数据元素和无用属性，并舍入几何属性中的数字
+func cleanSVGNode(node *svgNode, opts *svgOptimizeOptions, references map[string]bool) {
+	attrs := node.attrs[:0]
+	for _, attr := range node.attrs {
+		switch {
+		case editorPrefixes[attr.Name.Space]:
+			continue
+		case attr.Name.Space == "xmlns" && editorPrefixes[attr.Name.Local]:
+			continue
+		case attr.Name.Space == "" && attr.Name.Local == "id" && !opts.keepIds && !references[attr.Value]:
+			continue
+		case attr.Name.Space == "" && unitOpacityAttrs[attr.Name.Local] && isUnitValue(attr.Value):
+			continue
+		case attr.Name.Space == "" && numericAttrs[attr.Name.Local]:
+			attr.Value = roundSVGNumbers(attr.Value, opts.precision)
+		}
+		attrs = append(attrs, attr)
+	}
+	node.attrs = attrs
+
+	children := node.children[:0]
+	for _, child := range node.children {
+		if !child.isText {
+			if child.name.Local == "metadata" || editorPrefixes[child.name.Space] {
+				continue
+			}
+			// defs中没有被引用的定义不会被渲染
+			if node.name.Local == "defs" && !hasReferencedId(child, references) {
+				continue
+			}
+			cleanSVGNode(child, opts, references)
+			// 清理后为空的分组和defs没有作用
+			if (child.name.Local == "g" || child.name.Local == "defs") && len(child.children) == 0 && !hasReferencedId(child, references) {
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	node.children = children
+}
+
+func hasReferencedId(node *svgNode, references map[string]bool) bool {
+	id := svgAttr(node, "id")
+	return id != "" && references[id]
+}
+
+func isUnitValue(value string) bool {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil && v == 1
+}
+
+// collapseGroups 展开没有属性的分组；只有一个子元素的分组把属性下移到子元素
+func collapseGroups(node *svgNode) {
+	var children []*svgNode
+	for _, child := range node.children {
+		if child.isText {
+			children = append(children, child)
+			continue
+		}
+		collapseGroups(child)
+
+		if child.name.Local != "g" || child.name.Space != "" {
+			children = append(children, child)
+			continue
+		}
+
+		if len(child.attrs) == 0 {
+			children = append(children, child.children...)
+			continue
+		}
+
+		if only := singleElementChild(child); only != nil && canMoveGroupAttrs(child, only) {
+			moveGroupAttrs(child, only)
+			children = append(children, only)
+			continue
+		}
+
+		children = append(children, child)
+	}
+	node.children = children
+}
+
+func singleElementChild(node *svgNode) *svgNode {
+	if len(node.children) != 1 || node.children[0].isText {
+		return nil
+	}
+	return node.children[0]
+}
+
+// canMoveGroupAttrs 组的属性都能安全地下移到唯一的子元素上
+func canMoveGroupAttrs(group, child *svgNode) bool {
+	for _, attr := range group.attrs {
+		if attr.Name.Space != "" || groupOnlyAttrs[attr.Name.Local] {
+			return false
+		}
+		// 不透明度会相乘，子元素也有同名属性时不能简单覆盖
+		if unitOpacityAttrs[attr.Name.Local] && svgAttr(child, attr.Name.Local) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func moveGroupAttrs(group, child *svgNode) {
+	for _, attr := range group.attrs {
+		if attr.Name.Local == "transform" {
+			if existing := svgAttr(child, "transform"); existing != "" {
+				setSVGAttr(child, "transform", attr.Value+" "+existing)
+			} else {
+				setSVGAttr(child, "transform", attr.Value)
+			}
+			continue
+		}
+		// 子元素自己的属性覆盖继承的属性
+		if svgAttr(child, attr.Name.Local) == "" {
+			child.attrs = append(child.attrs, attr)
+		}
+	}
+}
+
+// 内部颜色不直接显示的元素，取色和替换时跳过
+var paintlessElements = map[string]bool{
+	"clipPath": true,
+	"mask":     true,
+}
+
+// collectPaintColors 收集fill和stroke中的纯色，忽略none、渐变引用和currentColor
+func collectPaintColors(node *svgNode, colors map[string]bool) {
+	if paintlessElements[node.name.Local] {
+		return
+	}
+	for _, attr := range node.attrs {
+		if attr.Name.Space == "" && (attr.Name.Local == "fill" || attr.Name.Local == "stroke" || attr.Name.Local == "stop-color") {
+			if color := paintColor(attr.Value); color != "" {
+				colors[color] = true
+			}
+		}
+	}
+	for _, child := range node.children {
+		if !child.isText {
+			collectPaintColors(child, colors)
+		}
+	}
+}
+
+func replacePaintColor(node *svgNode, color string) {
+	if paintlessElements[node.name.Local] {
+		return
+	}
+	for i, attr := range node.attrs {
+		if attr.Name.Space == "" && (attr.Name.Local == "fill" || attr.Name.Local == "stroke") && paintColor(attr.Value) == color {
+			node.attrs[i].Value = "currentColor"
+		}
+	}
+	for _, child := range node.children {
+		if !child.isText {
+			replacePaintColor(child, color)
+		}
+	}
+}
+
+// paintColor 规范化颜色值，不是纯色时返回空字符串
+func paintColor(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "none" || value == "currentcolor" || value == "transparent" || strings.HasPrefix(value, "url(") {
+		return ""
+	}
+	return value
+}
+
+// roundSVGNumbers 舍入属性值中的数字，必要时补空格避免相邻数字粘连
+// 以0开头的多位数字(例如弧线命令的紧凑标志位011)保持原样
+func roundSVGNumbers(value string, precision int) string {
+	var sb strings.Builder
+	var prev byte
+	last := 0
+	for _, loc := range svgNumberPattern.FindAllStringIndex(value, -1) {
+		between := value[last:loc[0]]
+		sb.WriteString(between)
+		if between != "" {
+			prev = between[len(between)-1]
+		}
+
+		number := value[loc[0]:loc[1]]
+		if len(number) > 1 && number[0] == '0' && number[1] >= '0' && number[1] <= '9' {
+			sb.WriteString(number)
+		} else {
+			if v, err := strconv.ParseFloat(number, 64); err == nil {
+				number = formatSVGNumber(v, precision)
+			}
+			if number[0] != '-' && ((prev >= '0' && prev <= '9') || prev == '.') {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(number)
+		}
+
+		prev = number[len(number)-1]
+		last = loc[1]
+	}
+	sb.WriteString(value[last:])
+	return sb.String()
+}
+
+func formatSVGNumber(v float64, precision int) string {
+	factor := math.Pow(10, float64(precision))
+	rounded := math.Round(v*factor) / factor
+	if rounded == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func svgAttr(node *svgNode, name string) string {
+	for _, attr := range node.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func setSVGAttr(node *svgNode, name, value string) {
+	for i, attr := range node.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			node.attrs[i].Value = value
+			return
+		}
+	}
+	node.attrs = append(node.attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// writeSVGNode 按原始前缀输出节点，没有子节点的元素使用自闭合标签
+func writeSVGNode(buf *bytes.Buffer, node *svgNode) {
+	writeSVGElement(buf, node, "")
+}
+
+// writeSVGElement 输出节点，extra原样追加在元素自身的属性之后，例如模板的属性展开语法
+func writeSVGElement(buf *bytes.Buffer, node *svgNode, extra string) {
+	if node.isText {
+		xml.EscapeText(buf, []byte(node.text))
+		return
+	}
+
+	name := rawName(node.name)
+	buf.WriteString("<" + name)
+	for _, attr := range node.attrs {
+		buf.WriteString(" " + rawName(attr.Name) + `="`)
+		xml.EscapeText(buf, []byte(attr.Value))
+		buf.WriteString(`"`)
+	}
+	if extra != "" {
+		buf.WriteString(" " + extra)
+	}
+
+	if len(node.children) == 0 {
+		buf.WriteString("/>")
+		return
+	}
+
+	buf.WriteString(">")
+	for _, child := range node.children {
+		writeSVGNode(buf, child)
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
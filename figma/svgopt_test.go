@@ -0,0 +1,161 @@
+package figma
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRoundSVGNumbers(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		precision int
+		want      string
+	}{
+		{"舍入", "M1.23456 7.891", 2, "M1.23 7.89"},
+		{"负数作分隔", "M-1.234-5.678", 2, "M-1.23-5.68"},
+		{"舍入为零", "M-0.0001 0.0004", 3, "M0 0"},
+		{"紧凑的弧线标志位", "a10 10 0 011 5 5", 3, "a10 10 0 011 5 5"},
+		{"合并的小数", "M1.5.5", 3, "M1.5 0.5"},
+		{"舍入后补空格", "M1.234.5", 1, "M1.2 0.5"},
+		{"指数", "M1e2-3 2.5e-1", 3, "M100-3 0.25"},
+		{"指数舍入为零", "M1e-5 1E+1", 3, "M0 10"},
+		{"变换", "matrix(1.00004 0 0 1 10.5 -0.25)", 2, "matrix(1 0 0 1 10.5 -0.25)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundSVGNumbers(tt.value, tt.precision); got != tt.want {
+				t.Fatalf("roundSVGNumbers(%q, %d) = %q，期望 %q", tt.value, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapseGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want string
+	}{
+		{
+			"展开没有属性的分组",
+			`<svg><g><path d="M0 0"/><path d="M1 1"/></g></svg>`,
+			`<svg><path d="M0 0"/><path d="M1 1"/></svg>`,
+		},
+		{
+			"变换下移到子元素",
+			`<svg><g transform="translate(1 2)" fill="red"><path d="M0 0"/></g></svg>`,
+			`<svg><path d="M0 0" transform="translate(1 2)" fill="red"/></svg>`,
+		},
+		{
+			"组的变换在子元素的变换之前",
+			`<svg><g transform="translate(1 2)"><path transform="scale(2)" d="M0 0"/></g></svg>`,
+			`<svg><path transform="translate(1 2) scale(2)" d="M0 0"/></svg>`,
+		},
+		{
+			"子元素的属性优先",
+			`<svg><g fill="red"><path fill="blue" d="M0 0"/></g></svg>`,
+			`<svg><path fill="blue" d="M0 0"/></svg>`,
+		},
+		{
+			"嵌套分组",
+			`<svg><g transform="scale(2)"><g transform="translate(1 2)"><path d="M0 0"/></g></g></svg>`,
+			`<svg><path d="M0 0" transform="scale(2) translate(1 2)"/></svg>`,
+		},
+		{
+			"裁剪路径留在组上",
+			`<svg><g clip-path="url(#a)"><path d="M0 0"/></g></svg>`,
+			`<svg><g clip-path="url(#a)"><path d="M0 0"/></g></svg>`,
+		},
+		{
+			"不透明度不能覆盖",
+			`<svg><g opacity="0.5"><path opacity="0.5" d="M0 0"/></g></svg>`,
+			`<svg><g opacity="0.5"><path opacity="0.5" d="M0 0"/></g></svg>`,
+		},
+		{
+			"多个子元素的分组保留",
+			`<svg><g fill="red"><path d="M0 0"/><path d="M1 1"/></g></svg>`,
+			`<svg><g fill="red"><path d="M0 0"/><path d="M1 1"/></g></svg>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseSVG([]byte(tt.svg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collapseGroups(root)
+
+			var buf bytes.Buffer
+			writeSVGNode(&buf, root)
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("collapseGroups 得到\n%s\n期望\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptimizeSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		opts svgOptimizeOptions
+		want string
+	}{
+		{
+			"删除注释和元数据",
+			`<?xml version="1.0"?><!-- Figma --><svg xmlns="http://www.w3.org/2000/svg" xmlns:sketch="http://www.bohemiancoding.com/sketch/ns"><metadata>x</metadata><path sketch:type="MSShapeGroup" d="M0.12345 1" opacity="1"/></svg>`,
+			svgOptimizeOptions{precision: 2},
+			`<svg xmlns="http://www.w3.org/2000/svg"><path d="M0.12 1"/></svg>`,
+		},
+		{
+			"保留被引用的裁剪路径ID",
+			`<svg><g clip-path="url(#clip0)"><path id="p" d="M0 0"/></g><defs><clipPath id="clip0"><rect width="24" height="24"/></clipPath><clipPath id="unused"><rect width="1" height="1"/></clipPath></defs></svg>`,
+			svgOptimizeOptions{precision: 3},
+			`<svg><g clip-path="url(#clip0)"><path d="M0 0"/></g><defs><clipPath id="clip0"><rect width="24" height="24"/></clipPath></defs></svg>`,
+		},
+		{
+			"保留样式中引用的ID",
+			`<svg><style>.a{fill:url('#g')}</style><defs><linearGradient id="g"/></defs><path class="a" d="M0 0"/></svg>`,
+			svgOptimizeOptions{precision: 3},
+			`<svg><style>.a{fill:url(&#39;#g&#39;)}</style><defs><linearGradient id="g"/></defs><path class="a" d="M0 0"/></svg>`,
+		},
+		{
+			"includeId时保留ID",
+			`<svg><path id="p" d="M0 0"/></svg>`,
+			svgOptimizeOptions{precision: 3, keepIds: true},
+			`<svg><path id="p" d="M0 0"/></svg>`,
+		},
+		{
+			"唯一颜色替换为currentColor",
+			`<svg><path fill="#000" d="M0 0"/><path stroke="#000" fill="none" d="M1 1"/></svg>`,
+			svgOptimizeOptions{precision: 3, currentColor: true},
+			`<svg><path fill="currentColor" d="M0 0"/><path stroke="currentColor" fill="none" d="M1 1"/></svg>`,
+		},
+		{
+			"多种颜色不替换",
+			`<svg><path fill="#000" d="M0 0"/><path fill="#fff" d="M1 1"/></svg>`,
+			svgOptimizeOptions{precision: 3, currentColor: true},
+			`<svg><path fill="#000" d="M0 0"/><path fill="#fff" d="M1 1"/></svg>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := optimizeSVG([]byte(tt.svg), &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("optimizeSVG 得到\n%s\n期望\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptimizeSVGRejectsInvalid(t *testing.T) {
+	for _, svg := range []string{"", "<div/>", "<svg><path", "<svg/><svg/>"} {
+		if _, err := optimizeSVG([]byte(svg), &svgOptimizeOptions{}); err == nil {
+			t.Fatalf("optimizeSVG(%q) 应当返回错误", svg)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package figma
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"figma-mcp-server/types"
+)
+
+// textSegment 一段使用同一覆盖样式ID的文字
+type textSegment struct {
+	text       string
+	overrideId int
+}
+
+// splitTextSegments 按characterStyleOverrides把文字切分为连续的片段
+// 下标是UTF-16编码单元，超出数组长度的字符使用基础样式
+func splitTextSegments(characters string, overrides []int) []textSegment {
+	units := utf16.Encode([]rune(characters))
+
+	var segments []textSegment
+	start := 0
+	for i := 1; i <= len(units); i++ {
+		if i < len(units) && overrideAt(overrides, i) == overrideAt(overrides, start) {
+			continue
+		}
+		segments = append(segments, textSegment{
+			text:       string(utf16.Decode(units[start:i])),
+			overrideId: overrideAt(overrides, start),
+		})
+		start = i
+	}
+
+	return segments
+}
+
+func overrideAt(overrides []int, i int) int {
+	if i < len(overrides) {
+		return overrides[i]
+	}
+	return 0
+}
+
+// mergeTextStyle 将覆盖样式合并到基础样式上
+func mergeTextStyle(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// buildTextRuns 解码富文本覆盖样式，生成带样式引用和链接的文字片段
+// 只有一段且没有链接的普通文本返回nil
+func buildTextRuns(figmaNode types.FigmaNode, vars *globalVars) ([]types.TextRun, []map[string]interface{}) {
+	segments := splitTextSegments(figmaNode.Characters, figmaNode.CharacterStyleOverrides)
+
+	var runs []types.TextRun
+	var runStyles []map[string]interface{}
+	hasLink := false
+
+	for _, segment := range segments {
+		style := figmaNode.Style
+		if segment.overrideId != 0 {
+			if override, ok := figmaNode.StyleOverrideTable[strconv.Itoa(segment.overrideId)]; ok {
+				style = mergeTextStyle(figmaNode.Style, override)
+			}
+		}
+
+		run := types.TextRun{Text: segment.text}
+		if len(style) > 0 {
+			run.TextStyle = vars.findOrCreate(simplifyTextStyle(style), "style")
+		}
+		if fills, ok := style["fills"].([]interface{}); ok && len(fills) > 0 {
+			run.Fills = vars.findOrCreate(fills, "fill")
+		}
+		if link, ok := style["hyperlink"].(map[string]interface{}); ok {
+			switch link["type"] {
+			case "URL":
+				run.Hyperlink, _ = link["url"].(string)
+			case "NODE":
+				run.LinkedNodeId, _ = link["nodeID"].(string)
+			}
+			hasLink = hasLink || run.Hyperlink != "" || run.LinkedNodeId != ""
+		}
+
+		runs = append(runs, run)
+		runStyles = append(runStyles, style)
+	}
+
+	if len(runs) <= 1 && !hasLink {
+		return nil, nil
+	}
+	return runs, runStyles
+}
+
+// renderTextMarkdown 将文字片段渲染为Markdown，相对基础样式的加粗、斜体、删除线和链接会被保留
+func renderTextMarkdown(runs []types.TextRun, runStyles []map[string]interface{}, base map[string]interface{}) string {
+	baseWeight, _ := floatValue(base, "fontWeight")
+	baseItalic, _ := base["italic"].(bool)
+	baseStrike := base["textDecoration"] == "STRIKETHROUGH"
+
+	var sb strings.Builder
+	for i, run := range runs {
+		style := runStyles[i]
+
+		var openMark, closeMark string
+		if weight, _ := floatValue(style, "fontWeight"); weight >= 600 && baseWeight < 600 {
+			openMark, closeMark = openMark+"**", "**"+closeMark
+		}
+		if italic, _ := style["italic"].(bool); italic && !baseItalic {
+			openMark, closeMark = openMark+"_", "_"+closeMark
+		}
+		if style["textDecoration"] == "STRIKETHROUGH" && !baseStrike {
+			openMark, closeMark = openMark+"~~", "~~"+closeMark
+		}
+
+		link := run.Hyperlink
+		if link == "" && run.LinkedNodeId != "" {
+			link = "#" + run.LinkedNodeId
+		}
+
+		// 按行分别加标记，避免强调符号跨越换行失效
+		lines := strings.Split(run.Text, "\n")
+		for j, line := range lines {
+			if j > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(decorateMarkdown(line, openMark, closeMark, link))
+		}
+	}
+
+	return sb.String()
+}
+
+// decorateMarkdown 给一行文字加上强调标记和链接，首尾空白保留在标记之外
+func decorateMarkdown(line, openMark, closeMark, link string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return line
+	}
+
+	leading := line[:strings.Index(line, trimmed)]
+	trailing := line[len(leading)+len(trimmed):]
+
+	text := openMark + escapeMarkdown(trimmed) + closeMark
+	if link != "" {
+		text = "[" + text + "](" + link + ")"
+	}
+	return leading + text + trailing
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
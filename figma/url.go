@@ -0,0 +1,121 @@
+package figma
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FigmaURL 从Figma链接中解析出的文件和节点
+type FigmaURL struct {
+	// Kind 链接类型：file、design、proto、board或slides
+	Kind      string `json:"kind"`
+	FileKey   string `json:"fileKey"`
+	BranchKey string `json:"branchKey,omitempty"`
+	NodeId    string `json:"nodeId,omitempty"`
+}
+
+// APIFileKey 调用REST API时使用的文件ID，分支链接使用分支的ID
+func (u FigmaURL) APIFileKey() string {
+	if u.BranchKey != "" {
+		return u.BranchKey
+	}
+	return u.FileKey
+}
+
+// 支持的链接类型
+var figmaURLKinds = map[string]bool{
+	"file":   true,
+	"design": true,
+	"proto":  true,
+	"board":  true,
+	"slides": true,
+}
+
+var (
+	fileKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+	nodeIdPattern  = regexp.MustCompile(`^I?\d+:\d+(;I?\d+:\d+)*$`)
+)
+
+// ParseFigmaURL 解析figma.com/{file,design,proto,board,slides}/<fileKey>/...链接，
+// 支持/branch/<branchKey>分支链接，并把node-id中的12-345转换为API使用的12:345
+func ParseFigmaURL(rawURL string) (FigmaURL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return FigmaURL{}, fmt.Errorf("无法解析链接 %q: %v", rawURL, err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "figma.com" && !strings.HasSuffix(host, ".figma.com") {
+		return FigmaURL{}, fmt.Errorf("链接 %q 不是figma.com的链接", rawURL)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || !figmaURLKinds[segments[0]] {
+		return FigmaURL{}, fmt.Errorf("不支持的Figma链接路径 %q，应为 /file/<fileKey>、/design/<fileKey>、/proto/<fileKey>、/board/<fileKey> 或 /slides/<fileKey>", u.Path)
+	}
+
+	result := FigmaURL{Kind: segments[0], FileKey: segments[1]}
+	if err := ValidateFileKey(result.FileKey); err != nil {
+		return FigmaURL{}, err
+	}
+
+	if len(segments) >= 4 && segments[2] == "branch" {
+		result.BranchKey = segments[3]
+		if err := ValidateFileKey(result.BranchKey); err != nil {
+			return FigmaURL{}, fmt.Errorf("分支%v", err)
+		}
+	}
+
+	if nodeId := queryValue(u.RawQuery, "node-id"); nodeId != "" {
+		if result.NodeId, err = NormalizeNodeId(nodeId); err != nil {
+			return FigmaURL{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// queryValue 读取查询参数；url.Query会丢弃含分号的参数，而实例节点ID中可能出现未编码的分号
+func queryValue(rawQuery, key string) string {
+	for _, pair := range strings.Split(rawQuery, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		if name != key {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			return unescaped
+		}
+		return value
+	}
+	return ""
+}
+
+// ValidateFileKey 检查fileKey是否为文件ID，常见错误是传入了文件的显示名称
+func ValidateFileKey(fileKey string) error {
+	if fileKey == "" {
+		return fmt.Errorf("fileKey为空")
+	}
+	if !fileKeyPattern.MatchString(fileKey) {
+		return fmt.Errorf("fileKey %q 不是有效的文件ID：文件ID只包含字母和数字，是链接中/design/或/file/后面的一段，而不是文件名", fileKey)
+	}
+	return nil
+}
+
+// NormalizeNodeId 把链接中的12-345格式转换为API使用的12:345，已经是冒号格式的ID保持不变
+func NormalizeNodeId(nodeId string) (string, error) {
+	normalized := strings.TrimSpace(nodeId)
+	if !strings.Contains(normalized, ":") {
+		normalized = strings.ReplaceAll(normalized, "-", ":")
+	}
+	if !nodeIdPattern.MatchString(normalized) {
+		return "", fmt.Errorf("节点ID %q 无效，应为链接中的12-345或API使用的12:345格式", nodeId)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,127 @@
+package figma
+
+import "testing"
+
+func TestParseFigmaURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		rawURL string
+		want   FigmaURL
+		apiKey string
+	}{
+		{
+			"设计链接",
+			"https://www.figma.com/design/AbC123/My-File?node-id=12-345&t=xyz",
+			FigmaURL{Kind: "design", FileKey: "AbC123", NodeId: "12:345"},
+			"AbC123",
+		},
+		{
+			"没有协议和节点",
+			"figma.com/file/AbC123",
+			FigmaURL{Kind: "file", FileKey: "AbC123"},
+			"AbC123",
+		},
+		{
+			"分支链接",
+			"https://www.figma.com/design/AbC123/branch/Br456/My-File?node-id=1-2",
+			FigmaURL{Kind: "design", FileKey: "AbC123", BranchKey: "Br456", NodeId: "1:2"},
+			"Br456",
+		},
+		{
+			"编码的冒号",
+			"https://www.figma.com/proto/AbC123/My-File?node-id=1%3A2",
+			FigmaURL{Kind: "proto", FileKey: "AbC123", NodeId: "1:2"},
+			"AbC123",
+		},
+		{
+			"实例节点",
+			"https://www.figma.com/design/AbC123/My-File?node-id=I1-2;3-4",
+			FigmaURL{Kind: "design", FileKey: "AbC123", NodeId: "I1:2;3:4"},
+			"AbC123",
+		},
+		{
+			"编码的实例节点",
+			"https://www.figma.com/board/AbC123/My-File?node-id=I1-2%3BI3-4",
+			FigmaURL{Kind: "board", FileKey: "AbC123", NodeId: "I1:2;I3:4"},
+			"AbC123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFigmaURL(tt.rawURL)
+			if err != nil {
+				t.Fatalf("ParseFigmaURL(%q) 返回错误: %v", tt.rawURL, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseFigmaURL(%q) = %+v，期望 %+v", tt.rawURL, got, tt.want)
+			}
+			if key := got.APIFileKey(); key != tt.apiKey {
+				t.Fatalf("APIFileKey() = %q，期望 %q", key, tt.apiKey)
+			}
+		})
+	}
+}
+
+func TestParseFigmaURLRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		rawURL string
+	}{
+		{"其他域名", "https://example.com/design/AbC123"},
+		{"伪装的域名", "https://figma.com.example.com/design/AbC123"},
+		{"不支持的路径", "https://www.figma.com/community/file/AbC123"},
+		{"缺少fileKey", "https://www.figma.com/design"},
+		{"显示名称作为fileKey", "https://www.figma.com/design/My%20Design%20File"},
+		{"无效的分支", "https://www.figma.com/design/AbC123/branch/my-branch/x"},
+		{"无效的节点", "https://www.figma.com/design/AbC123/x?node-id=frame"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseFigmaURL(tt.rawURL); err == nil {
+				t.Fatalf("ParseFigmaURL(%q) = %+v，应当返回错误", tt.rawURL, got)
+			}
+		})
+	}
+}
+
+func TestValidateFileKey(t *testing.T) {
+	for _, fileKey := range []string{"", "My Design File", "设计稿", "AbC123/x"} {
+		if err := ValidateFileKey(fileKey); err == nil {
+			t.Fatalf("ValidateFileKey(%q) 应当返回错误", fileKey)
+		}
+	}
+	if err := ValidateFileKey("AbC123"); err != nil {
+		t.Fatalf("ValidateFileKey(%q) 返回错误: %v", "AbC123", err)
+	}
+}
+
+func TestNormalizeNodeId(t *testing.T) {
+	tests := []struct {
+		nodeId string
+		want   string
+	}{
+		{"12-345", "12:345"},
+		{"12:345", "12:345"},
+		{" 1-2 ", "1:2"},
+		{"I1-2;3-4", "I1:2;3:4"},
+		{"I1:2;I3:4", "I1:2;I3:4"},
+		{"", ""},
+		{"1-2-3", ""},
+		{"frame", ""},
+		{"1:2;", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nodeId, func(t *testing.T) {
+			got, err := NormalizeNodeId(tt.nodeId)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("NormalizeNodeId(%q) = %q，应当返回错误", tt.nodeId, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("NormalizeNodeId(%q) = %q, %v，期望 %q", tt.nodeId, got, err, tt.want)
+			}
+		})
+	}
+}
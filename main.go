@@ -7,18 +7,32 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"figma-mcp-server/figma"
 	"figma-mcp-server/server"
 )
 
 func main() {
 	port := flag.Int("port", 3333, "服务器端口")
+	downloadRoot := flag.String("download-root", "", "允许下载写入的根目录，多个用逗号分隔，默认为当前工作目录")
 	flag.Parse()
 
+	if *downloadRoot != "" {
+		if err := figma.SetDownloadRoots(strings.Split(*downloadRoot, ",")); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
 	fmt.Printf("配置:\n")
 	fmt.Printf("- 端口: %d\n", *port)
 	fmt.Printf("- 认证方式: 从请求参数获取API Key\n")
+	if *downloadRoot != "" {
+		fmt.Printf("- 下载根目录: %s\n", *downloadRoot)
+	} else {
+		fmt.Printf("- 下载根目录: 当前工作目录\n")
+	}
 
 	fmt.Printf("\n正在初始化 Figma MCP Server (HTTP 模式) 端口 %d...\n", *port)
 
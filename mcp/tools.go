@@ -1,7 +1,10 @@
 package mcp
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"figma-mcp-server/figma"
 	"figma-mcp-server/types"
@@ -20,25 +23,68 @@ func GetAvailableTools() []types.Tool {
 						"type":        "string",
 						"description": "Figma API认证密钥",
 					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Figma链接，例如https://www.figma.com/design/<fileKey>/Name?node-id=12-345，会解析出fileKey、nodeId和分支；支持file、design、proto、board和slides链接",
+					},
+					"fileKey": map[string]interface{}{
+						"type":        "string",
+						"description": "Figma文件ID(链接中/design/后面的一段，不是文件名)，提供url时可省略",
+					},
+					"nodeId": map[string]interface{}{
+						"type":        "string",
+						"description": "特定节点ID，12:345或链接中的12-345格式均可，优先于url中的node-id",
+					},
+					"nodeIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "一次获取多个节点，nodes按请求的节点ID分组返回，不存在的节点为null；与nodeId或url中的node-id同时提供时一并获取",
+					},
+					"depth": map[string]interface{}{
+						"type":        "number",
+						"description": "遍历深度，对节点和整个文件都生效；获取整个文件时1只返回页面，2返回页面及其顶层节点",
+					},
+					"index": map[string]interface{}{
+						"type":        "boolean",
+						"description": "只返回页面及其直接子节点的ID、名称、类型和尺寸，用于在获取具体节点之前浏览大文件；不能与nodeId、nodeIds或带node-id的链接同时使用",
+					},
+				},
+				"required": []string{"figmaApiKey"},
+			},
+		},
+		{
+			Name:        "list_figma_assets",
+			Description: "列出Figma文件或节点中可下载的图片填充、矢量图标和带导出设置的节点",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"figmaApiKey": map[string]interface{}{
+						"type":        "string",
+						"description": "Figma API认证密钥",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Figma链接，例如https://www.figma.com/design/<fileKey>/Name?node-id=12-345，会解析出fileKey、nodeId和分支；支持file、design、proto、board和slides链接",
+					},
 					"fileKey": map[string]interface{}{
 						"type":        "string",
-						"description": "Figma文件ID",
+						"description": "Figma文件ID(链接中/design/后面的一段，不是文件名)，提供url时可省略",
 					},
 					"nodeId": map[string]interface{}{
 						"type":        "string",
-						"description": "特定节点ID",
+						"description": "特定节点ID，不填则遍历整个文件；12:345或12-345格式均可，优先于url中的node-id",
 					},
 					"depth": map[string]interface{}{
 						"type":        "number",
-						"description": "遍历深度",
+						"description": "遍历深度，对节点和整个文件都生效",
 					},
 				},
-				"required": []string{"figmaApiKey", "fileKey"},
+				"required": []string{"figmaApiKey"},
 			},
 		},
 		{
 			Name:        "download_figma_images",
-			Description: "下载Figma文件中的SVG/PNG图像",
+			Description: "下载Figma文件中的PNG/JPG/SVG/PDF图像",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -46,45 +92,182 @@ func GetAvailableTools() []types.Tool {
 						"type":        "string",
 						"description": "Figma API认证密钥",
 					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Figma链接，例如https://www.figma.com/design/<fileKey>/Name?node-id=12-345，会解析出fileKey、nodeId和分支；支持file、design、proto、board和slides链接",
+					},
 					"fileKey": map[string]interface{}{
 						"type":        "string",
-						"description": "Figma文件ID",
+						"description": "Figma文件ID(链接中/design/后面的一段，不是文件名)，提供url时可省略",
 					},
 					"nodes": map[string]interface{}{
 						"type":        "array",
-						"description": "包含nodeId、fileName等的节点数组",
+						"description": "包含nodeId、fileName等的节点数组，格式由fileName扩展名(.png/.jpg/.svg/.pdf)决定，也可用format显式指定；scale为该节点位图的缩放比例，优先于pngScale；图片填充的扩展名按实际内容更正",
 					},
 					"localPath": map[string]interface{}{
 						"type":        "string",
-						"description": "本地存储路径",
+						"description": "本地存储路径，相对路径基于服务器的下载根目录，必须位于允许的下载根目录内；设置inline或archive时可省略",
 					},
 					"pngScale": map[string]interface{}{
 						"type":        "number",
-						"description": "PNG缩放比例",
+						"description": "PNG/JPG缩放比例",
+					},
+					"pngScales": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "number"},
+						"description": "多倍图缩放比例列表，如[1, 2, 3]，设置后代替pngScale",
+					},
+					"scaleLayout": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"suffix", "android"},
+						"description": "多倍图命名方式：suffix生成name@2x.png，android生成drawable-xxhdpi/name.png",
 					},
 					"svgOptions": map[string]interface{}{
 						"type":        "object",
-						"description": "SVG导出选项",
+						"description": "SVG导出选项：outlineText、includeId、simplifyStroke传给Figma；optimize开启本地优化(删除元数据和未引用的ID、合并分组、舍入数字)，precision为保留的小数位(默认3)，currentColor把唯一的颜色替换为currentColor",
+					},
+					"concurrency": map[string]interface{}{
+						"type":        "number",
+						"description": "同时下载的文件数，默认8，最多32",
+					},
+					"fileTimeout": map[string]interface{}{
+						"type":        "number",
+						"description": "单个文件的下载超时(秒)，默认60",
+					},
+					"batchSize": map[string]interface{}{
+						"type":        "number",
+						"description": "每次Figma渲染请求包含的节点数，默认50",
+					},
+					"useExportSettings": map[string]interface{}{
+						"type":        "boolean",
+						"description": "按节点在Figma中配置的导出设置(格式、后缀、尺寸约束)生成所有文件，此时fileName可省略",
+					},
+					"overwrite": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"overwrite", "skip", "rename"},
+						"description": "目标文件已存在时的处理：overwrite覆盖(默认)，skip跳过，rename追加序号另存",
+					},
+					"inline": map[string]interface{}{
+						"type":        "boolean",
+						"description": "在结果中直接返回文件内容：PNG/JPG为base64图像，SVG为文本，PDF不内联。设置后localPath可省略",
+					},
+					"archive": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"resource", "url"},
+						"description": "将所有文件和manifest.json打包为zip：resource作为嵌入资源返回(上限20MB)，url返回10分钟内有效的下载链接。设置后localPath可省略",
+					},
+					"sprite": map[string]interface{}{
+						"type":        "string",
+						"description": "把下载的SVG合并为<symbol>雪碧图的文件名，例如icons.svg，symbol ID由节点名生成",
+					},
+					"components": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"react", "vue", "svelte"},
+						"description": "为每个SVG生成带类型的图标组件和index.ts，组件名由节点名转换为PascalCase",
+					},
+					"componentDir": map[string]interface{}{
+						"type":        "string",
+						"description": "组件和index.ts的目录，相对于localPath，默认icons",
+					},
+					"maxInlineBytes": map[string]interface{}{
+						"type":        "number",
+						"description": "单个文件内联的大小上限(字节)，默认1048576，单次内联总量不超过5MB",
 					},
 				},
-				"required": []string{"figmaApiKey", "fileKey", "nodes", "localPath"},
+				"required": []string{"figmaApiKey", "nodes"},
 			},
 		},
 	}
 }
 
+// 作为嵌入资源返回的压缩包大小上限
+const maxEmbeddedArchiveBytes = 20 << 20
+
+// PublishFunc 将文件发布为短期有效的下载链接并返回URL
+type PublishFunc func(name, mimeType string, data []byte) (string, error)
+
+// CallOptions 由传输层提供给工具调用的能力
+type CallOptions struct {
+	// Progress 上报进度，调用方没有提供progressToken时为nil
+	Progress figma.ProgressFunc
+	// Publish 发布临时下载链接，传输层不支持时为nil
+	Publish PublishFunc
+}
+
 // CallTool 调用指定的工具
-func CallTool(toolName string, arguments map[string]interface{}) (interface{}, error) {
+func CallTool(toolName string, arguments map[string]interface{}, options CallOptions) (interface{}, error) {
 	switch toolName {
 	case "get_figma_data":
 		return callGetFigmaData(arguments)
+	case "list_figma_assets":
+		return callListFigmaAssets(arguments)
 	case "download_figma_images":
-		return callDownloadFigmaImages(arguments)
+		return callDownloadFigmaImages(arguments, options)
 	default:
 		return nil, fmt.Errorf("未知工具: %s", toolName)
 	}
 }
 
+// resolveFileTarget 从url或fileKey、nodeId参数中确定文件和节点
+// 同时提供时显式的nodeId优先于链接中的node-id，分支链接使用分支的文件ID
+func resolveFileTarget(args map[string]interface{}) (fileKey, nodeId string, err error) {
+	if rawURL, ok := args["url"].(string); ok && rawURL != "" {
+		parsed, err := figma.ParseFigmaURL(rawURL)
+		if err != nil {
+			return "", "", err
+		}
+		fileKey, nodeId = parsed.APIFileKey(), parsed.NodeId
+	} else if fileKey, ok = args["fileKey"].(string); !ok {
+		return "", "", fmt.Errorf("缺少必需参数: fileKey或url")
+	} else if err := figma.ValidateFileKey(fileKey); err != nil {
+		return "", "", err
+	}
+
+	if id, ok := args["nodeId"].(string); ok && id != "" {
+		if nodeId, err = figma.NormalizeNodeId(id); err != nil {
+			return "", "", err
+		}
+	}
+	return fileKey, nodeId, nil
+}
+
+// nodeIdList 解析nodeIds数组参数，nodeId不为空时排在最前面，重复的ID只保留一次
+// 未提供nodeIds时返回nil
+func nodeIdList(raw interface{}, nodeId string) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("nodeIds必须是字符串数组")
+	}
+
+	var nodeIds []string
+	seen := make(map[string]bool)
+	if nodeId != "" {
+		nodeIds = append(nodeIds, nodeId)
+		seen[nodeId] = true
+	}
+	for i, item := range items {
+		id, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("nodeIds[%d]必须是字符串", i)
+		}
+		normalized, err := figma.NormalizeNodeId(id)
+		if err != nil {
+			return nil, fmt.Errorf("nodeIds[%d]: %v", i, err)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			nodeIds = append(nodeIds, normalized)
+		}
+	}
+	if len(nodeIds) == 0 {
+		return nil, fmt.Errorf("nodeIds不能为空")
+	}
+	return nodeIds, nil
+}
+
 func callGetFigmaData(args map[string]interface{}) (interface{}, error) {
 	// 提取参数
 	figmaApiKey, ok := args["figmaApiKey"].(string)
@@ -92,12 +275,20 @@ func callGetFigmaData(args map[string]interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("缺少必需参数: figmaApiKey")
 	}
 
-	fileKey, ok := args["fileKey"].(string)
-	if !ok {
-		return nil, fmt.Errorf("缺少必需参数: fileKey")
+	fileKey, nodeId, err := resolveFileTarget(args)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIds, err := nodeIdList(args["nodeIds"], nodeId)
+	if err != nil {
+		return nil, err
 	}
 
-	nodeId, _ := args["nodeId"].(string)
+	index, _ := args["index"].(bool)
+	if index && (nodeId != "" || nodeIds != nil) {
+		return nil, fmt.Errorf("index模式只适用于整个文件，不能与nodeId、nodeIds或带node-id的链接同时使用")
+	}
 
 	var depth int
 	if d, ok := args["depth"].(float64); ok {
@@ -105,7 +296,14 @@ func callGetFigmaData(args map[string]interface{}) (interface{}, error) {
 	}
 
 	// 调用Figma服务
-	result, err := figma.GetFigmaData(figmaApiKey, fileKey, nodeId, depth)
+	var result string
+	if index {
+		result, err = figma.GetFigmaFileIndex(figmaApiKey, fileKey)
+	} else if nodeIds != nil {
+		result, err = figma.GetFigmaNodes(figmaApiKey, fileKey, nodeIds, depth)
+	} else {
+		result, err = figma.GetFigmaData(figmaApiKey, fileKey, nodeId, depth)
+	}
 	if err != nil {
 		return types.ToolResult{
 			Content: []types.Content{{
@@ -124,16 +322,53 @@ func callGetFigmaData(args map[string]interface{}) (interface{}, error) {
 	}, nil
 }
 
-func callDownloadFigmaImages(args map[string]interface{}) (interface{}, error) {
+func callListFigmaAssets(args map[string]interface{}) (interface{}, error) {
 	// 提取参数
 	figmaApiKey, ok := args["figmaApiKey"].(string)
 	if !ok {
 		return nil, fmt.Errorf("缺少必需参数: figmaApiKey")
 	}
 
-	fileKey, ok := args["fileKey"].(string)
+	fileKey, nodeId, err := resolveFileTarget(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var depth int
+	if d, ok := args["depth"].(float64); ok {
+		depth = int(d)
+	}
+
+	// 调用Figma服务
+	result, err := figma.ListFigmaAssets(figmaApiKey, fileKey, nodeId, depth)
+	if err != nil {
+		return types.ToolResult{
+			Content: []types.Content{{
+				Type: "text",
+				Text: fmt.Sprintf("错误: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	return types.ToolResult{
+		Content: []types.Content{{
+			Type: "text",
+			Text: result,
+		}},
+	}, nil
+}
+
+func callDownloadFigmaImages(args map[string]interface{}, options CallOptions) (interface{}, error) {
+	// 提取参数
+	figmaApiKey, ok := args["figmaApiKey"].(string)
 	if !ok {
-		return nil, fmt.Errorf("缺少必需参数: fileKey")
+		return nil, fmt.Errorf("缺少必需参数: figmaApiKey")
+	}
+
+	fileKey, _, err := resolveFileTarget(args)
+	if err != nil {
+		return nil, err
 	}
 
 	nodes, ok := args["nodes"].([]interface{})
@@ -141,11 +376,29 @@ func callDownloadFigmaImages(args map[string]interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("缺少必需参数: nodes")
 	}
 
+	// 内联返回或打包时可以不写入本地目录
+	inline, _ := args["inline"].(bool)
+	archive, _ := args["archive"].(string)
+	switch archive {
+	case "", "resource":
+	case "url":
+		if options.Publish == nil {
+			return nil, fmt.Errorf("当前连接不支持临时下载链接，请使用archive: resource")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的archive: %s", archive)
+	}
+
 	localPath, ok := args["localPath"].(string)
-	if !ok {
+	if !ok && !inline && archive == "" {
 		return nil, fmt.Errorf("缺少必需参数: localPath")
 	}
 
+	var maxInlineBytes int
+	if m, ok := args["maxInlineBytes"].(float64); ok {
+		maxInlineBytes = int(m)
+	}
+
 	pngScale := 1.0
 	if scale, ok := args["pngScale"].(float64); ok {
 		pngScale = scale
@@ -156,9 +409,57 @@ func callDownloadFigmaImages(args map[string]interface{}) (interface{}, error) {
 		svgOptions = opts
 	}
 
+	var pngScales []float64
+	if scales, ok := args["pngScales"].([]interface{}); ok {
+		for _, scale := range scales {
+			if value, ok := scale.(float64); ok && value > 0 {
+				pngScales = append(pngScales, value)
+			}
+		}
+	}
+
+	scaleLayout, _ := args["scaleLayout"].(string)
+	useExportSettings, _ := args["useExportSettings"].(bool)
+	overwrite, _ := args["overwrite"].(string)
+	sprite, _ := args["sprite"].(string)
+	components, _ := args["components"].(string)
+	componentDir, _ := args["componentDir"].(string)
+
+	var concurrency int
+	if c, ok := args["concurrency"].(float64); ok {
+		concurrency = int(c)
+	}
+
+	var batchSize int
+	if b, ok := args["batchSize"].(float64); ok {
+		batchSize = int(b)
+	}
+
+	var fileTimeout time.Duration
+	if t, ok := args["fileTimeout"].(float64); ok && t > 0 {
+		fileTimeout = time.Duration(t * float64(time.Second))
+	}
+
 	// 调用Figma服务
-	err := figma.DownloadFigmaImages(figmaApiKey, fileKey, nodes, localPath, pngScale, svgOptions)
-	if err != nil {
+	report, err := figma.DownloadFigmaImages(figmaApiKey, fileKey, nodes, localPath, figma.DownloadOptions{
+		PngScale:          pngScale,
+		PngScales:         pngScales,
+		ScaleLayout:       scaleLayout,
+		SvgOptions:        svgOptions,
+		UseExportSettings: useExportSettings,
+		Concurrency:       concurrency,
+		FileTimeout:       fileTimeout,
+		BatchSize:         batchSize,
+		Progress:          options.Progress,
+		Overwrite:         overwrite,
+		Inline:            inline,
+		MaxInlineBytes:    maxInlineBytes,
+		Archive:           archive != "",
+		Sprite:            sprite,
+		Components:        components,
+		ComponentDir:      componentDir,
+	})
+	if err != nil && report == nil {
 		return types.ToolResult{
 			Content: []types.Content{{
 				Type: "text",
@@ -168,10 +469,83 @@ func callDownloadFigmaImages(args map[string]interface{}) (interface{}, error) {
 		}, nil
 	}
 
+	// 返回每个文件的下载结果，部分失败时不影响其余文件
+	reportJSON, jsonErr := json.MarshalIndent(report, "", "  ")
+	if jsonErr != nil {
+		return types.ToolResult{}, jsonErr
+	}
+
+	text := string(reportJSON)
+	if err != nil {
+		text = fmt.Sprintf("错误: %v\n%s", err, reportJSON)
+	}
+
+	content := []types.Content{{
+		Type: "text",
+		Text: text,
+	}}
+
+	// 位图作为image内容返回，SVG作为文本返回
+	for _, result := range report.Results {
+		if !result.Inlined {
+			continue
+		}
+		if result.Format == "svg" {
+			content = append(content, types.Content{
+				Type: "text",
+				Text: string(result.Data),
+			})
+			continue
+		}
+		content = append(content, types.Content{
+			Type:     "image",
+			Data:     base64.StdEncoding.EncodeToString(result.Data),
+			MimeType: figma.InlineMimeType(result.Format),
+		})
+	}
+
+	if archive != "" && report.Downloaded+report.Unchanged+report.Duplicates > 0 {
+		archiveContent, archiveErr := archiveResult(fileKey, report, archive, options.Publish)
+		if archiveErr != nil {
+			return types.ToolResult{}, archiveErr
+		}
+		content = append(content, archiveContent)
+	}
+
 	return types.ToolResult{
-		Content: []types.Content{{
+		Content: content,
+		IsError: err != nil,
+	}, nil
+}
+
+// archiveResult 打包下载结果，作为嵌入资源返回或发布为临时下载链接
+func archiveResult(fileKey string, report *types.DownloadReport, mode string, publish PublishFunc) (types.Content, error) {
+	data, err := figma.BuildArchive(fileKey, report)
+	if err != nil {
+		return types.Content{}, fmt.Errorf("打包失败: %v", err)
+	}
+
+	name := fmt.Sprintf("figma-%s-assets.zip", fileKey)
+	if mode == "url" {
+		link, err := publish(name, "application/zip", data)
+		if err != nil {
+			return types.Content{}, fmt.Errorf("发布下载链接失败: %v", err)
+		}
+		return types.Content{
 			Type: "text",
-			Text: "成功下载图像",
-		}},
+			Text: fmt.Sprintf("压缩包下载链接(10分钟内有效): %s", link),
+		}, nil
+	}
+
+	if len(data) > maxEmbeddedArchiveBytes {
+		return types.Content{}, fmt.Errorf("压缩包大小 %d 字节超过嵌入上限 %d 字节，请使用archive: url", len(data), maxEmbeddedArchiveBytes)
+	}
+	return types.Content{
+		Type: "resource",
+		Resource: &types.EmbeddedResource{
+			URI:      fmt.Sprintf("figma://%s/%s", fileKey, name),
+			MimeType: "application/zip",
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		},
 	}, nil
 }
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"figma-mcp-server/mcp"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// 临时下载链接的有效期
+	artifactTTL = 10 * time.Minute
+	// 单个临时文件的大小上限
+	maxArtifactBytes = 100 << 20
+	// 所有临时文件占用内存的上限，超出时先删除最早的文件
+	maxArtifactTotalBytes = 500 << 20
+)
+
+// artifact 一个可以通过临时链接下载的文件
+type artifact struct {
+	name      string
+	mimeType  string
+	data      []byte
+	expiresAt time.Time
+}
+
+// artifactStore 保存在内存中的临时下载文件，过期后删除
+// 单个文件和总大小都有上限，避免反复打包耗尽服务器内存
+type artifactStore struct {
+	mu    sync.Mutex
+	items map[string]*artifact
+	total int
+}
+
+func newArtifactStore() *artifactStore {
+	return &artifactStore{items: make(map[string]*artifact)}
+}
+
+// put 保存文件并返回随机令牌；超过单个文件上限时拒绝，总大小超限时删除最早的文件
+func (a *artifactStore) put(name, mimeType string, data []byte) (string, error) {
+	if len(data) > maxArtifactBytes {
+		return "", fmt.Errorf("文件大小 %d 字节超过临时下载链接的上限 %dMB，请减少节点数量或使用localPath", len(data), maxArtifactBytes>>20)
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for key, item := range a.items {
+		if now.After(item.expiresAt) {
+			a.remove(key)
+		}
+	}
+	for a.total+len(data) > maxArtifactTotalBytes {
+		a.remove(a.oldest())
+	}
+
+	a.items[token] = &artifact{
+		name:      name,
+		mimeType:  mimeType,
+		data:      data,
+		expiresAt: now.Add(artifactTTL),
+	}
+	a.total += len(data)
+	return token, nil
+}
+
+// oldest 返回最早过期(即最早保存)的文件的令牌，调用方需持有锁
+func (a *artifactStore) oldest() string {
+	var oldestKey string
+	var oldestAt time.Time
+	for key, item := range a.items {
+		if oldestKey == "" || item.expiresAt.Before(oldestAt) {
+			oldestKey, oldestAt = key, item.expiresAt
+		}
+	}
+	return oldestKey
+}
+
+// remove 删除文件并更新总大小，调用方需持有锁
+func (a *artifactStore) remove(token string) {
+	if item, ok := a.items[token]; ok {
+		a.total -= len(item.data)
+		delete(a.items, token)
+	}
+}
+
+func (a *artifactStore) get(token string) (*artifact, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	item, ok := a.items[token]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expiresAt) {
+		a.remove(token)
+		return nil, false
+	}
+	return item, true
+}
+
+// publisher 返回发布函数，链接的协议和主机取自当前请求
+func (s *Server) publisher(r *http.Request) mcp.PublishFunc {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+
+	return func(name, mimeType string, data []byte) (string, error) {
+		token, err := s.artifacts.put(name, mimeType, data)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s://%s/downloads/%s", scheme, host, token), nil
+	}
+}
+
+func (s *Server) downloadsHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.artifacts.get(mux.Vars(r)["token"])
+	if !ok {
+		http.Error(w, "下载链接不存在或已过期", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", item.mimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(item.data)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", item.name))
+	w.Write(item.data)
+}
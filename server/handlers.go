@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"figma-mcp-server/mcp"
@@ -40,8 +41,16 @@ func (s *Server) mcpHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("[INFO] Handling StreamableHTTP request")
 
+	// 进度通知在最终响应之前以SSE事件发送
+	var mu sync.Mutex
+	notify := func(notification *types.MCPNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		s.sendSSEMessage(w, notification)
+	}
+
 	// 处理MCP请求
-	response := s.handleMCPRequest(&req, sessionID)
+	response := s.handleMCPRequest(&req, sessionID, notify, s.publisher(r))
 
 	// 发送SSE响应
 	s.sendSSEResponse(w, response)
@@ -66,14 +75,14 @@ func (s *Server) messagesHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleMCPRequest(req *types.MCPRequest, sessionID string) *types.MCPResponse {
+func (s *Server) handleMCPRequest(req *types.MCPRequest, sessionID string, notify func(*types.MCPNotification), publish mcp.PublishFunc) *types.MCPResponse {
 	switch req.Method {
 	case "initialize":
 		return s.handleInitialize(req, sessionID)
 	case "tools/list":
 		return s.handleToolsList(req)
 	case "tools/call":
-		return s.handleToolsCall(req)
+		return s.handleToolsCall(req, notify, publish)
 	default:
 		return &types.MCPResponse{
 			JSONRPC: "2.0",
@@ -120,7 +129,7 @@ func (s *Server) handleToolsList(req *types.MCPRequest) *types.MCPResponse {
 	}
 }
 
-func (s *Server) handleToolsCall(req *types.MCPRequest) *types.MCPResponse {
+func (s *Server) handleToolsCall(req *types.MCPRequest, notify func(*types.MCPNotification), publish mcp.PublishFunc) *types.MCPResponse {
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
 		return &types.MCPResponse{
@@ -157,8 +166,27 @@ func (s *Server) handleToolsCall(req *types.MCPRequest) *types.MCPResponse {
 		}
 	}
 
+	// 调用方在_meta中提供progressToken时才上报进度
+	options := mcp.CallOptions{Publish: publish}
+	if meta, ok := params["_meta"].(map[string]interface{}); ok && notify != nil {
+		if progressToken, exists := meta["progressToken"]; exists && progressToken != nil {
+			options.Progress = func(completed, total int, message string) {
+				notify(&types.MCPNotification{
+					JSONRPC: "2.0",
+					Method:  "notifications/progress",
+					Params: map[string]interface{}{
+						"progressToken": progressToken,
+						"progress":      completed,
+						"total":         total,
+						"message":       message,
+					},
+				})
+			}
+		}
+	}
+
 	// 调用工具
-	result, err := mcp.CallTool(toolName, arguments)
+	result, err := mcp.CallTool(toolName, arguments, options)
 	if err != nil {
 		return &types.MCPResponse{
 			JSONRPC: "2.0",
@@ -178,7 +206,12 @@ func (s *Server) handleToolsCall(req *types.MCPRequest) *types.MCPResponse {
 }
 
 func (s *Server) sendSSEResponse(w http.ResponseWriter, response *types.MCPResponse) {
-	data, _ := json.Marshal(response)
+	s.sendSSEMessage(w, response)
+}
+
+// sendSSEMessage 以SSE message事件发送任意JSON-RPC消息
+func (s *Server) sendSSEMessage(w http.ResponseWriter, message interface{}) {
+	data, _ := json.Marshal(message)
 	fmt.Fprintf(w, "event: message\ndata: %s\n\n", string(data))
 	w.(http.Flusher).Flush()
 }
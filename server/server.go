@@ -10,8 +10,9 @@ import (
 )
 
 type Server struct {
-	router   *mux.Router
-	sessions map[string]*Session
+	router    *mux.Router
+	sessions  map[string]*Session
+	artifacts *artifactStore
 }
 
 type HealthResponse struct {
@@ -23,8 +24,9 @@ type HealthResponse struct {
 
 func NewServer() http.Handler {
 	s := &Server{
-		router:   mux.NewRouter(),
-		sessions: make(map[string]*Session),
+		router:    mux.NewRouter(),
+		sessions:  make(map[string]*Session),
+		artifacts: newArtifactStore(),
 	}
 
 	s.setupRoutes()
@@ -44,6 +46,7 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/mcp", s.mcpHandler).Methods("POST")
 	s.router.HandleFunc("/sse", s.sseHandler).Methods("GET")
 	s.router.HandleFunc("/messages", s.messagesHandler).Methods("POST")
+	s.router.HandleFunc("/downloads/{token}", s.downloadsHandler).Methods("GET")
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -10,24 +10,62 @@ type SimplifiedDesign struct {
 	Components    map[string]interface{} `json:"components"`
 	ComponentSets map[string]interface{} `json:"componentSets"`
 	GlobalVars    GlobalVars             `json:"globalVars"`
+
+	// DownloadCandidates 折叠后的SVG图标等可直接传给download_figma_images的节点
+	DownloadCandidates []DownloadCandidate `json:"downloadCandidates,omitempty"`
 }
 
 type SimplifiedNode struct {
-	ID                  string              `json:"id"`
-	Name                string              `json:"name"`
-	Type                string              `json:"type"`
-	Text                string              `json:"text,omitempty"`
-	TextStyle           string              `json:"textStyle,omitempty"`
-	Fills               string              `json:"fills,omitempty"`
-	Styles              string              `json:"styles,omitempty"`
-	Strokes             string              `json:"strokes,omitempty"`
-	Effects             string              `json:"effects,omitempty"`
-	Opacity             *float64            `json:"opacity,omitempty"`
-	BorderRadius        string              `json:"borderRadius,omitempty"`
-	Layout              string              `json:"layout,omitempty"`
-	ComponentId         string              `json:"componentId,omitempty"`
-	ComponentProperties []ComponentProperty `json:"componentProperties,omitempty"`
-	Children            []SimplifiedNode    `json:"children,omitempty"`
+	ID                  string              `json:"id" yaml:"id"`
+	Name                string              `json:"name" yaml:"name"`
+	Type                string              `json:"type" yaml:"type"`
+	Text                string              `json:"text,omitempty" yaml:"text,omitempty"`
+	TextStyle           string              `json:"textStyle,omitempty" yaml:"textStyle,omitempty"`
+	TextRuns            []TextRun           `json:"textRuns,omitempty" yaml:"textRuns,omitempty"`
+	Markdown            string              `json:"markdown,omitempty" yaml:"markdown,omitempty"`
+	Fills               string              `json:"fills,omitempty" yaml:"fills,omitempty"`
+	Styles              string              `json:"styles,omitempty" yaml:"styles,omitempty"`
+	Strokes             string              `json:"strokes,omitempty" yaml:"strokes,omitempty"`
+	Effects             string              `json:"effects,omitempty" yaml:"effects,omitempty"`
+	Opacity             *float64            `json:"opacity,omitempty" yaml:"opacity,omitempty"`
+	BorderRadius        string              `json:"borderRadius,omitempty" yaml:"borderRadius,omitempty"`
+	Layout              string              `json:"layout,omitempty" yaml:"layout,omitempty"`
+	ComponentId         string              `json:"componentId,omitempty" yaml:"componentId,omitempty"`
+	ComponentProperties []ComponentProperty `json:"componentProperties,omitempty" yaml:"componentProperties,omitempty"`
+	Children            []SimplifiedNode    `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// FileIndex 文件的页面和顶层节点索引，用于在深入获取之前浏览大文件
+type FileIndex struct {
+	Name         string      `json:"name" yaml:"name"`
+	LastModified string      `json:"lastModified" yaml:"lastModified"`
+	Version      string      `json:"version,omitempty" yaml:"version,omitempty"`
+	Pages        []PageIndex `json:"pages" yaml:"pages"`
+}
+
+// PageIndex 页面及其直接子节点
+type PageIndex struct {
+	ID       string       `json:"id" yaml:"id"`
+	Name     string       `json:"name" yaml:"name"`
+	Children []FrameIndex `json:"children" yaml:"children"`
+}
+
+// FrameIndex 页面的直接子节点，通常是顶层画框
+type FrameIndex struct {
+	ID     string  `json:"id" yaml:"id"`
+	Name   string  `json:"name" yaml:"name"`
+	Type   string  `json:"type" yaml:"type"`
+	Width  float64 `json:"width,omitempty" yaml:"width,omitempty"`
+	Height float64 `json:"height,omitempty" yaml:"height,omitempty"`
+}
+
+// TextRun 富文本中样式一致的一段文字
+type TextRun struct {
+	Text         string `json:"text" yaml:"text"`
+	TextStyle    string `json:"textStyle,omitempty" yaml:"textStyle,omitempty"`
+	Fills        string `json:"fills,omitempty" yaml:"fills,omitempty"`
+	Hyperlink    string `json:"hyperlink,omitempty" yaml:"hyperlink,omitempty"`
+	LinkedNodeId string `json:"linkedNodeId,omitempty" yaml:"linkedNodeId,omitempty"`
 }
 
 type BoundingBox struct {
@@ -38,29 +76,107 @@ type BoundingBox struct {
 }
 
 type ComponentProperty struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
-	Type  string `json:"type"`
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+	Type  string `json:"type" yaml:"type"`
 }
 
 type GlobalVars struct {
-	Styles map[string]interface{} `json:"styles"`
+	Styles map[string]interface{} `json:"styles" yaml:"styles"`
 }
 
 // Layout structures to match NodeJS implementation
+// 布局会像fills一样去重后存入globalVars，节点的Layout字段保存变量ID
 type SimplifiedLayout struct {
-	Mode                     string                 `json:"mode"`
-	JustifyContent           string                 `json:"justifyContent,omitempty"`
-	AlignItems               string                 `json:"alignItems,omitempty"`
-	AlignSelf                string                 `json:"alignSelf,omitempty"`
-	Wrap                     *bool                  `json:"wrap,omitempty"`
-	Gap                      string                 `json:"gap,omitempty"`
-	LocationRelativeToParent map[string]interface{} `json:"locationRelativeToParent,omitempty"`
-	Dimensions               map[string]interface{} `json:"dimensions,omitempty"`
-	Padding                  string                 `json:"padding,omitempty"`
-	Sizing                   map[string]interface{} `json:"sizing,omitempty"`
-	OverflowScroll           []string               `json:"overflowScroll,omitempty"`
-	Position                 string                 `json:"position,omitempty"`
+	Mode                     string                 `json:"mode" yaml:"mode"`
+	JustifyContent           string                 `json:"justifyContent,omitempty" yaml:"justifyContent,omitempty"`
+	AlignItems               string                 `json:"alignItems,omitempty" yaml:"alignItems,omitempty"`
+	AlignSelf                string                 `json:"alignSelf,omitempty" yaml:"alignSelf,omitempty"`
+	Wrap                     *bool                  `json:"wrap,omitempty" yaml:"wrap,omitempty"`
+	Gap                      string                 `json:"gap,omitempty" yaml:"gap,omitempty"`
+	LocationRelativeToParent map[string]interface{} `json:"locationRelativeToParent,omitempty" yaml:"locationRelativeToParent,omitempty"`
+	Dimensions               map[string]interface{} `json:"dimensions,omitempty" yaml:"dimensions,omitempty"`
+	Padding                  string                 `json:"padding,omitempty" yaml:"padding,omitempty"`
+	Sizing                   map[string]interface{} `json:"sizing,omitempty" yaml:"sizing,omitempty"`
+	OverflowScroll           []string               `json:"overflowScroll,omitempty" yaml:"overflowScroll,omitempty"`
+	Position                 string                 `json:"position,omitempty" yaml:"position,omitempty"`
+	Top                      string                 `json:"top,omitempty" yaml:"top,omitempty"`
+	Right                    string                 `json:"right,omitempty" yaml:"right,omitempty"`
+	Bottom                   string                 `json:"bottom,omitempty" yaml:"bottom,omitempty"`
+	Left                     string                 `json:"left,omitempty" yaml:"left,omitempty"`
+	Width                    string                 `json:"width,omitempty" yaml:"width,omitempty"`
+	Height                   string                 `json:"height,omitempty" yaml:"height,omitempty"`
+	Constraints              *SimplifiedConstraints `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	FlexGrow                 float64                `json:"flexGrow,omitempty" yaml:"flexGrow,omitempty"`
+	MinWidth                 string                 `json:"minWidth,omitempty" yaml:"minWidth,omitempty"`
+	MaxWidth                 string                 `json:"maxWidth,omitempty" yaml:"maxWidth,omitempty"`
+	MinHeight                string                 `json:"minHeight,omitempty" yaml:"minHeight,omitempty"`
+	MaxHeight                string                 `json:"maxHeight,omitempty" yaml:"maxHeight,omitempty"`
+	RowGap                   string                 `json:"rowGap,omitempty" yaml:"rowGap,omitempty"`
+	ColumnGap                string                 `json:"columnGap,omitempty" yaml:"columnGap,omitempty"`
+	AlignContent             string                 `json:"alignContent,omitempty" yaml:"alignContent,omitempty"`
+	GridTemplateColumns      string                 `json:"gridTemplateColumns,omitempty" yaml:"gridTemplateColumns,omitempty"`
+	GridTemplateRows         string                 `json:"gridTemplateRows,omitempty" yaml:"gridTemplateRows,omitempty"`
+	GridColumn               string                 `json:"gridColumn,omitempty" yaml:"gridColumn,omitempty"`
+	GridRow                  string                 `json:"gridRow,omitempty" yaml:"gridRow,omitempty"`
+	JustifySelf              string                 `json:"justifySelf,omitempty" yaml:"justifySelf,omitempty"`
+	StrokesIncludedInLayout  bool                   `json:"strokesIncludedInLayout,omitempty" yaml:"strokesIncludedInLayout,omitempty"`
+	RenderBounds             map[string]interface{} `json:"renderBounds,omitempty" yaml:"renderBounds,omitempty"`
+}
+
+// SimplifiedConstraints 约束转换后的锚定方式：
+// left/right/center/left-right/scale 和 top/bottom/center/top-bottom/scale
+type SimplifiedConstraints struct {
+	Horizontal string `json:"horizontal,omitempty" yaml:"horizontal,omitempty"`
+	Vertical   string `json:"vertical,omitempty" yaml:"vertical,omitempty"`
+}
+
+// SimplifiedTextStyle 文本样式，Figma的单位已转换为CSS单位
+type SimplifiedTextStyle struct {
+	FontFamily          string  `json:"fontFamily,omitempty" yaml:"fontFamily,omitempty"`
+	FontWeight          float64 `json:"fontWeight,omitempty" yaml:"fontWeight,omitempty"`
+	FontStyle           string  `json:"fontStyle,omitempty" yaml:"fontStyle,omitempty"`
+	FontSize            string  `json:"fontSize,omitempty" yaml:"fontSize,omitempty"`
+	LineHeight          string  `json:"lineHeight,omitempty" yaml:"lineHeight,omitempty"`
+	LetterSpacing       string  `json:"letterSpacing,omitempty" yaml:"letterSpacing,omitempty"`
+	TextCase            string  `json:"textCase,omitempty" yaml:"textCase,omitempty"`
+	FontVariant         string  `json:"fontVariant,omitempty" yaml:"fontVariant,omitempty"`
+	TextDecoration      string  `json:"textDecoration,omitempty" yaml:"textDecoration,omitempty"`
+	TextAlignHorizontal string  `json:"textAlignHorizontal,omitempty" yaml:"textAlignHorizontal,omitempty"`
+	TextAlignVertical   string  `json:"textAlignVertical,omitempty" yaml:"textAlignVertical,omitempty"`
+}
+
+// SimplifiedEffects 效果转换后的CSS属性值
+type SimplifiedEffects struct {
+	BoxShadow      string `json:"boxShadow,omitempty" yaml:"boxShadow,omitempty"`
+	TextShadow     string `json:"textShadow,omitempty" yaml:"textShadow,omitempty"`
+	Filter         string `json:"filter,omitempty" yaml:"filter,omitempty"`
+	BackdropFilter string `json:"backdropFilter,omitempty" yaml:"backdropFilter,omitempty"`
+}
+
+// SimplifiedStroke 描边信息及其CSS等价写法
+type SimplifiedStroke struct {
+	Colors []string  `json:"colors,omitempty" yaml:"colors,omitempty"`
+	Weight string    `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Align  string    `json:"align,omitempty" yaml:"align,omitempty"`
+	Style  string    `json:"style,omitempty" yaml:"style,omitempty"`
+	Dashes []float64 `json:"dashes,omitempty" yaml:"dashes,omitempty"`
+	Cap    string    `json:"cap,omitempty" yaml:"cap,omitempty"`
+	Join   string    `json:"join,omitempty" yaml:"join,omitempty"`
+	CSS    StrokeCSS `json:"css" yaml:"css"`
+}
+
+// StrokeCSS 描边对应的CSS属性
+// inside描边使用border配合border-box，center和outside描边使用outline，
+// 逐边宽度不同时只能用border表达
+type StrokeCSS struct {
+	Border        string `json:"border,omitempty" yaml:"border,omitempty"`
+	BorderWidth   string `json:"borderWidth,omitempty" yaml:"borderWidth,omitempty"`
+	BorderStyle   string `json:"borderStyle,omitempty" yaml:"borderStyle,omitempty"`
+	BorderColor   string `json:"borderColor,omitempty" yaml:"borderColor,omitempty"`
+	BoxSizing     string `json:"boxSizing,omitempty" yaml:"boxSizing,omitempty"`
+	Outline       string `json:"outline,omitempty" yaml:"outline,omitempty"`
+	OutlineOffset string `json:"outlineOffset,omitempty" yaml:"outlineOffset,omitempty"`
 }
 
 type FigmaGetFileResult struct {
@@ -79,6 +195,7 @@ type SimplifiedDesignMetadata struct {
 type FigmaAPIResponse struct {
 	Name          string                 `json:"name"`
 	LastModified  string                 `json:"lastModified"`
+	Version       string                 `json:"version"`
 	ThumbnailUrl  string                 `json:"thumbnailUrl"`
 	Document      FigmaNode              `json:"document,omitempty"`
 	Components    map[string]interface{} `json:"components,omitempty"`
@@ -86,12 +203,13 @@ type FigmaAPIResponse struct {
 }
 
 type FigmaAPINodeResponse struct {
-	Name          string                      `json:"name"`
-	LastModified  string                      `json:"lastModified"`
-	ThumbnailUrl  string                      `json:"thumbnailUrl"`
-	Nodes         map[string]FigmaNodeWrapper `json:"nodes"`
-	Components    map[string]interface{}      `json:"components,omitempty"`
-	ComponentSets map[string]interface{}      `json:"componentSets,omitempty"`
+	Name          string                       `json:"name"`
+	LastModified  string                       `json:"lastModified"`
+	Version       string                       `json:"version"`
+	ThumbnailUrl  string                       `json:"thumbnailUrl"`
+	Nodes         map[string]*FigmaNodeWrapper `json:"nodes"`
+	Components    map[string]interface{}       `json:"components,omitempty"`
+	ComponentSets map[string]interface{}       `json:"componentSets,omitempty"`
 }
 
 type FigmaNodeWrapper struct {
@@ -118,8 +236,20 @@ type FigmaNode struct {
 	RectangleCornerRadii []float64              `json:"rectangleCornerRadii,omitempty"`
 	ComponentId          string                 `json:"componentId,omitempty"`
 	ComponentProperties  map[string]interface{} `json:"componentProperties,omitempty"`
+	ExportSettings       []ExportSetting        `json:"exportSettings,omitempty"`
 	Children             []FigmaNode            `json:"children,omitempty"`
 
+	// Stroke details
+	StrokeDashes            []float64      `json:"strokeDashes,omitempty"`
+	StrokeCap               string         `json:"strokeCap,omitempty"`
+	StrokeJoin              string         `json:"strokeJoin,omitempty"`
+	IndividualStrokeWeights *StrokeWeights `json:"individualStrokeWeights,omitempty"`
+
+	// Rich text properties for text nodes
+	// characterStyleOverrides按UTF-16下标对应styleOverrideTable中的ID，0表示基础样式
+	CharacterStyleOverrides []int                             `json:"characterStyleOverrides,omitempty"`
+	StyleOverrideTable      map[string]map[string]interface{} `json:"styleOverrideTable,omitempty"`
+
 	// Layout properties for frame nodes
 	LayoutMode             string   `json:"layoutMode,omitempty"`
 	PrimaryAxisAlignItems  string   `json:"primaryAxisAlignItems,omitempty"`
@@ -137,6 +267,73 @@ type FigmaNode struct {
 	LayoutGrow             *float64 `json:"layoutGrow,omitempty"`
 	OverflowDirection      []string `json:"overflowDirection,omitempty"`
 	PreserveRatio          bool     `json:"preserveRatio,omitempty"`
+
+	// Constraints 节点相对父节点的约束，决定父节点缩放时如何定位
+	Constraints *LayoutConstraint `json:"constraints,omitempty"`
+
+	// Auto-layout sizing limits and wrapping
+	MinWidth                *float64     `json:"minWidth,omitempty"`
+	MaxWidth                *float64     `json:"maxWidth,omitempty"`
+	MinHeight               *float64     `json:"minHeight,omitempty"`
+	MaxHeight               *float64     `json:"maxHeight,omitempty"`
+	CounterAxisSpacing      *float64     `json:"counterAxisSpacing,omitempty"`
+	CounterAxisAlignContent string       `json:"counterAxisAlignContent,omitempty"`
+	StrokesIncludedInLayout bool         `json:"strokesIncludedInLayout,omitempty"`
+	AbsoluteRenderBounds    *BoundingBox `json:"absoluteRenderBounds,omitempty"`
+
+	// Grid layout (layoutMode为GRID)的容器和子节点属性
+	GridRowCount             int      `json:"gridRowCount,omitempty"`
+	GridColumnCount          int      `json:"gridColumnCount,omitempty"`
+	GridRowGap               *float64 `json:"gridRowGap,omitempty"`
+	GridColumnGap            *float64 `json:"gridColumnGap,omitempty"`
+	GridRowsSizing           string   `json:"gridRowsSizing,omitempty"`
+	GridColumnsSizing        string   `json:"gridColumnsSizing,omitempty"`
+	GridRowSpan              *int     `json:"gridRowSpan,omitempty"`
+	GridColumnSpan           *int     `json:"gridColumnSpan,omitempty"`
+	GridRowAnchorIndex       *int     `json:"gridRowAnchorIndex,omitempty"`
+	GridColumnAnchorIndex    *int     `json:"gridColumnAnchorIndex,omitempty"`
+	GridChildHorizontalAlign string   `json:"gridChildHorizontalAlign,omitempty"`
+	GridChildVerticalAlign   string   `json:"gridChildVerticalAlign,omitempty"`
+}
+
+// LayoutConstraint 水平方向为LEFT/RIGHT/CENTER/LEFT_RIGHT/SCALE，
+// 垂直方向为TOP/BOTTOM/CENTER/TOP_BOTTOM/SCALE
+type LayoutConstraint struct {
+	Vertical   string `json:"vertical"`
+	Horizontal string `json:"horizontal"`
+}
+
+// StrokeWeights 逐边描边宽度
+type StrokeWeights struct {
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+}
+
+// DownloadCandidate 建议下载的资源节点，nodeId、imageRef和fileName可直接传给download_figma_images
+type DownloadCandidate struct {
+	Kind      string  `json:"kind,omitempty" yaml:"kind,omitempty"`
+	NodeId    string  `json:"nodeId" yaml:"nodeId"`
+	Name      string  `json:"name" yaml:"name"`
+	ImageRef  string  `json:"imageRef,omitempty" yaml:"imageRef,omitempty"`
+	ScaleMode string  `json:"scaleMode,omitempty" yaml:"scaleMode,omitempty"`
+	FileName  string  `json:"fileName" yaml:"fileName"`
+	Format    string  `json:"format,omitempty" yaml:"format,omitempty"`
+	Scale     float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+}
+
+// ExportSetting 节点上配置的导出设置
+type ExportSetting struct {
+	Suffix     string           `json:"suffix"`
+	Format     string           `json:"format"`
+	Constraint ExportConstraint `json:"constraint"`
+}
+
+// ExportConstraint 导出尺寸约束，Type为SCALE、WIDTH或HEIGHT
+type ExportConstraint struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
 }
 
 // 图像下载相关的类型定义
@@ -144,6 +341,9 @@ type ImageNode struct {
 	NodeId   string `json:"nodeId"`
 	ImageRef string `json:"imageRef,omitempty"`
 	FileName string `json:"fileName"`
+	Format   string `json:"format,omitempty"`
+	// Scale 该节点位图的缩放比例，优先于pngScale和pngScales
+	Scale float64 `json:"scale,omitempty"`
 }
 
 type SVGOptions struct {
@@ -152,6 +352,84 @@ type SVGOptions struct {
 	SimplifyStroke bool `json:"simplifyStroke"`
 }
 
+// DownloadResult 单个输出文件的下载结果
+// Status为downloaded、unchanged、duplicate、failed或skipped
+type DownloadResult struct {
+	NodeId   string `json:"nodeId"`
+	ImageRef string `json:"imageRef,omitempty"`
+	FileName string `json:"fileName"`
+	Path     string `json:"path,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	// Inlined 文件内容是否随结果直接返回，InlineNote说明未内联的原因
+	Inlined    bool   `json:"inlined,omitempty"`
+	InlineNote string `json:"inlineNote,omitempty"`
+	// SHA256 文件内容的哈希，DuplicateOf表示内容与同批次中先写入的另一个文件相同
+	SHA256      string `json:"sha256,omitempty"`
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+	// Data 内联返回的文件内容，不参与JSON序列化
+	Data []byte `json:"-"`
+}
+
+// DownloadReport 一次下载任务的汇总
+type DownloadReport struct {
+	LocalPath  string           `json:"localPath"`
+	Total      int              `json:"total"`
+	Downloaded int              `json:"downloaded"`
+	Unchanged  int              `json:"unchanged"`
+	Duplicates int              `json:"duplicates"`
+	Failed     int              `json:"failed"`
+	Skipped    int              `json:"skipped"`
+	Results    []DownloadResult `json:"results"`
+	// Manifest 写入localPath的下载清单，只下载到内存时为nil
+	Manifest *DownloadManifest `json:"manifest,omitempty"`
+	// Generated 由SVG生成的雪碧图、组件和索引文件
+	Generated []GeneratedFile `json:"generated,omitempty"`
+	// Warnings 不影响下载结果的问题，例如无法读取文件版本
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GeneratedFile 由下载的SVG生成的文件，Kind为sprite、component或index
+type GeneratedFile struct {
+	Kind      string `json:"kind"`
+	FileName  string `json:"fileName"`
+	Path      string `json:"path,omitempty"`
+	Bytes     int64  `json:"bytes"`
+	NodeId    string `json:"nodeId,omitempty"`
+	Component string `json:"component,omitempty"`
+	// Status 写入磁盘时为written，文件已存在且覆盖策略为skip时为skipped
+	Status string `json:"status,omitempty"`
+	// Data 文件内容，用于打包，不参与JSON序列化
+	Data []byte `json:"-"`
+}
+
+// DownloadManifest 保存在localPath中的下载清单，记录每个文件的来源版本和内容哈希
+type DownloadManifest struct {
+	FileKey      string                   `json:"fileKey"`
+	Version      string                   `json:"version,omitempty"`
+	LastModified string                   `json:"lastModified,omitempty"`
+	UpdatedAt    string                   `json:"updatedAt"`
+	Files        map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry 清单中的单个文件，以fileName为键
+type ManifestEntry struct {
+	NodeId       string  `json:"nodeId"`
+	ImageRef     string  `json:"imageRef,omitempty"`
+	Format       string  `json:"format,omitempty"`
+	Scale        float64 `json:"scale,omitempty"`
+	Variant      string  `json:"variant,omitempty"`
+	Version      string  `json:"version,omitempty"`
+	LastModified string  `json:"lastModified,omitempty"`
+	SHA256       string  `json:"sha256"`
+	Bytes        int64   `json:"bytes"`
+	DuplicateOf  string  `json:"duplicateOf,omitempty"`
+}
+
 // Figma Images API响应
 type FigmaImagesResponse struct {
 	Images map[string]string `json:"images"`
@@ -16,6 +16,13 @@ type MCPResponse struct {
 	ID      interface{} `json:"id,omitempty"`
 }
 
+// MCPNotification 没有ID的JSON-RPC通知，例如notifications/progress
+type MCPNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type MCPError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -40,7 +47,17 @@ type ToolResult struct {
 }
 
 type Content struct {
-	Type     string `json:"type"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *EmbeddedResource `json:"resource,omitempty"`
+}
+
+// EmbeddedResource 随工具结果返回的资源，二进制内容使用base64编码的Blob
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
 	Text     string `json:"text,omitempty"`
-	Resource string `json:"resource,omitempty"`
+	Blob     string `json:"blob,omitempty"`
 }